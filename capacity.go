@@ -0,0 +1,159 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Capacity is a storage size in bytes.
+type Capacity uint64
+
+// Binary and decimal capacity units
+const (
+	Byte Capacity = 1
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+
+	KB = 1000 * Byte
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+	PB = 1000 * TB
+)
+
+// The storage allocates volume space in units of MiB, the "MB" of the REST API.
+const allocationUnit = MiB
+
+var capacityUnits = map[string]Capacity{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KB,
+	"kb":  KB,
+	"ki":  KiB,
+	"kib": KiB,
+	"m":   MB,
+	"mb":  MB,
+	"mi":  MiB,
+	"mib": MiB,
+	"g":   GB,
+	"gb":  GB,
+	"gi":  GiB,
+	"gib": GiB,
+	"t":   TB,
+	"tb":  TB,
+	"ti":  TiB,
+	"tib": TiB,
+	"p":   PB,
+	"pb":  PB,
+	"pi":  PiB,
+	"pib": PiB,
+}
+
+// ParseCapacity parses a capacity string like "10Gi", "500G", "1.5Ti" or "4096".
+// Binary suffixes (Ki, Mi, Gi, Ti, Pi) are powers of 1024, decimal suffixes (K, M, G, T, P) are powers of 1000,
+// an optional trailing "B" is accepted, and a number without suffix is in bytes.
+func ParseCapacity(s string) (Capacity, error) {
+	str := strings.TrimSpace(s)
+	i := 0
+	for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.') {
+		i++
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid capacity %q", s)
+	}
+
+	unit, ok := capacityUnits[strings.ToLower(strings.TrimSpace(str[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid capacity unit in %q", s)
+	}
+
+	if !strings.Contains(str[:i], ".") {
+		n, err := strconv.ParseUint(str[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid capacity %q: %v", s, err)
+		}
+		if n > math.MaxUint64/uint64(unit) {
+			return 0, fmt.Errorf("capacity %q overflows", s)
+		}
+		return Capacity(n) * unit, nil
+	}
+
+	f, err := strconv.ParseFloat(str[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid capacity %q: %v", s, err)
+	}
+	v := math.Ceil(f * float64(unit))
+	if v >= math.MaxUint64 {
+		return 0, fmt.Errorf("capacity %q overflows", s)
+	}
+
+	return Capacity(v), nil
+}
+
+// MustParseCapacity is like ParseCapacity but panics if the string cannot be parsed.
+func MustParseCapacity(s string) Capacity {
+	c, err := ParseCapacity(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// CapacityFromMB returns the capacity of the given size in MB as reported by the storage.
+func CapacityFromMB(mb uint64) Capacity {
+	return Capacity(mb) * allocationUnit
+}
+
+// Bytes returns the capacity in bytes.
+func (c Capacity) Bytes() uint64 {
+	return uint64(c)
+}
+
+// MB returns the capacity in MB units of the storage, rounded up to the allocation granularity,
+// so the allocated size is never smaller than the requested one.
+func (c Capacity) MB() uint64 {
+	mb := uint64(c / allocationUnit)
+	if c%allocationUnit != 0 {
+		mb++
+	}
+	return mb
+}
+
+// GiB returns the capacity in GiB.
+func (c Capacity) GiB() float64 {
+	return float64(c) / float64(GiB)
+}
+
+// RoundUp returns the capacity rounded up to the allocation granularity of the storage.
+func (c Capacity) RoundUp() Capacity {
+	return CapacityFromMB(c.MB())
+}
+
+// String returns the capacity with the largest binary unit that keeps it readable, ex "10Gi" or "1.5Ti".
+func (c Capacity) String() string {
+	units := []struct {
+		name string
+		size Capacity
+	}{
+		{"Pi", PiB}, {"Ti", TiB}, {"Gi", GiB}, {"Mi", MiB}, {"Ki", KiB},
+	}
+
+	for _, u := range units {
+		if c >= u.size {
+			if c%u.size == 0 {
+				return strconv.FormatUint(uint64(c/u.size), 10) + u.name
+			}
+			return strconv.FormatFloat(float64(c)/float64(u.size), 'f', 2, 64) + u.name
+		}
+	}
+
+	return strconv.FormatUint(uint64(c), 10)
+}
@@ -0,0 +1,97 @@
+package goqsm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestParseCapacity(t *testing.T) {
+	tests := []struct {
+		str  string
+		want Capacity
+	}{
+		{"4096", 4096},
+		{"10Gi", 10 * GiB},
+		{"500G", 500 * GB},
+		{"500GB", 500 * GB},
+		{"1.5Ti", TiB + 512*GiB},
+		{"100 Mi", 100 * MiB},
+		{"2k", 2000},
+		{"1Ki", 1024},
+		{"10GiB", 10 * GiB},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCapacity(tt.str)
+		if err != nil {
+			t.Errorf("ParseCapacity(%q) failed: %v", tt.str, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCapacity(%q) = %d, want %d", tt.str, got, tt.want)
+		}
+	}
+
+	for _, str := range []string{"", "Gi", "10Xi", "-1G", "1.2.3G", "99999999999Pi"} {
+		if _, err := ParseCapacity(str); err == nil {
+			t.Errorf("ParseCapacity(%q) expected an error", str)
+		}
+	}
+}
+
+func TestCapacityConversion(t *testing.T) {
+	tests := []struct {
+		c   Capacity
+		mb  uint64
+		str string
+	}{
+		{0, 0, "0"},
+		{1, 1, "1"},
+		{MiB, 1, "1Mi"},
+		{MiB + 1, 2, "1.00Mi"},
+		{10 * GiB, 10240, "10Gi"},
+		{500 * GB, 476838, "465.66Gi"},
+		{TiB + 512*GiB, 1572864, "1.50Ti"},
+	}
+
+	for _, tt := range tests {
+		if mb := tt.c.MB(); mb != tt.mb {
+			t.Errorf("Capacity(%d).MB() = %d, want %d", tt.c, mb, tt.mb)
+		}
+		if str := tt.c.String(); str != tt.str {
+			t.Errorf("Capacity(%d).String() = %q, want %q", tt.c, str, tt.str)
+		}
+		if r := tt.c.RoundUp(); r < tt.c || r%MiB != 0 {
+			t.Errorf("Capacity(%d).RoundUp() = %d", tt.c, r)
+		}
+	}
+
+	if c := CapacityFromMB(5120); c != 5*GiB || c.GiB() != 5 {
+		t.Errorf("CapacityFromMB(5120) = %s", c)
+	}
+}
+
+func TestCreateVolumeWithCapacity(t *testing.T) {
+	fake := newFakeServer(t)
+	fake.handle(http.MethodPost, "/rest/internal/cloud/containers/sc1/vols", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("sizeMB") != "476838" {
+			t.Errorf("unexpected sizeMB: %s", r.Form.Get("sizeMB"))
+		}
+		writeJSON(w, http.StatusOK, VolumeData{ID: "v1", SizeMB: 476838})
+	})
+	volumeOp := NewVolume(fake.authClient(t))
+
+	vol, err := volumeOp.CreateVolumeWithCapacity(context.Background(), "sc1", "vol1", MustParseCapacity("500G"), nil)
+	if err != nil {
+		t.Fatalf("CreateVolumeWithCapacity failed: %v", err)
+	}
+	if vol.Size() < 500*GB {
+		t.Fatalf("volume size %s is smaller than requested", vol.Size())
+	}
+
+	if _, err := volumeOp.CreateVolumeWithCapacity(context.Background(), "sc1", "vol2", 0, nil); err == nil {
+		t.Fatalf("CreateVolumeWithCapacity with zero size expected an error")
+	}
+}
@@ -0,0 +1,239 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContainerOp handles storage container related methods of the QSM storage.
+type ContainerOp struct {
+	client *AuthClient
+}
+
+// The response data of storage container related methods, ex ListContainers and CreateContainer.
+type ContainerData struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	SizeMB    uint64    `json:"sizeMB"`
+	UsedMB    uint64    `json:"usedMB"`
+	FreeMB    uint64    `json:"freeMB"`
+	PoolID    string    `json:"poolId"` // the pool backing the container
+	PoolName  string    `json:"poolName"`
+	RaidLevel RaidLevel `json:"raidLevel"` // RAID level of the pool
+
+	// VolumeDefaults are the options applied to a new volume when CreateVolume leaves them empty.
+	VolumeDefaults VolumeDefaults `json:"volumeDefaults"`
+}
+
+// VolumeDefaults are the default options of new volumes in a storage container.
+type VolumeDefaults struct {
+	BlockSize uint        `json:"blockSize"`
+	Provision Provision   `json:"provision"`
+	Compress  Compression `json:"compress"`
+	Dedup     string      `json:"dedup"` // "on" or "off"
+}
+
+// Options returns the defaults as the options of CreateVolume.
+func (d *VolumeDefaults) Options() VolumeCreateOptions {
+	return VolumeCreateOptions{
+		BlockSize: d.BlockSize,
+		Provision: d.Provision,
+		Compress:  d.Compress,
+		Dedup:     d.Dedup == "on",
+	}
+}
+
+// ContainerCreateOptions are optional settings of CreateContainer. Zero values keep the storage defaults.
+type ContainerCreateOptions struct {
+	VolumeDefaults *VolumeCreateOptions // default options of new volumes
+}
+
+// AmbiguousNameError is returned by ResolveContainerID when storage containers share the name.
+type AmbiguousNameError struct {
+	Name string
+	IDs  []string
+}
+
+func (e *AmbiguousNameError) Error() string {
+	return fmt.Sprintf("storage container name %q is ambiguous: %s", e.Name, strings.Join(e.IDs, ", "))
+}
+
+// nameCache maps storage container names to IDs for an AuthClient.
+type nameCache struct {
+	mu      sync.Mutex
+	ids     map[string][]string
+	expires time.Time
+}
+
+func (n *nameCache) lookup(name string, now time.Time) ([]string, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.ids == nil || now.After(n.expires) {
+		return nil, false
+	}
+	ids, ok := n.ids[name]
+	return ids, ok
+}
+
+func (n *nameCache) store(scs []ContainerData, expires time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.ids = map[string][]string{}
+	for _, sc := range scs {
+		n.ids[sc.Name] = append(n.ids[sc.Name], sc.ID)
+	}
+	n.expires = expires
+}
+
+func (n *nameCache) invalidate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.ids = nil
+}
+
+// Size returns the size of the container.
+func (d *ContainerData) Size() Capacity {
+	return CapacityFromMB(d.SizeMB)
+}
+
+// Free returns the free space of the container.
+func (d *ContainerData) Free() Capacity {
+	return CapacityFromMB(d.FreeMB)
+}
+
+// NewContainer returns storage container operation
+func NewContainer(client *AuthClient) *ContainerOp {
+	return &ContainerOp{client}
+}
+
+// ListContainers list all storage containers
+func (c *ContainerOp) ListContainers(ctx context.Context) (*[]ContainerData, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "/rest/internal/cloud/containers", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []ContainerData{}
+	if err := c.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ResolveContainerID returns the ID of the storage container with the name. The name to ID mapping is cached
+// per AuthClient for ClientOptions.NameCacheTTL. An error matching ErrNotFound is returned if no container
+// has the name, and AmbiguousNameError if more than one has.
+func (c *ContainerOp) ResolveContainerID(ctx context.Context, name string) (string, error) {
+	cache := &c.client.containerNames
+	ids, ok := cache.lookup(name, time.Now())
+	if !ok {
+		// Unknown names are fetched again, the container may be created after the cache was filled
+		scs, err := c.ListContainers(ctx)
+		if err != nil {
+			return "", err
+		}
+		if c.client.nameCacheTTL > 0 {
+			cache.store(*scs, time.Now().Add(c.client.nameCacheTTL))
+		}
+
+		ids = nil
+		for _, sc := range *scs {
+			if sc.Name == name {
+				ids = append(ids, sc.ID)
+			}
+		}
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("storage container %q: %w", name, ErrNotFound)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &AmbiguousNameError{Name: name, IDs: ids}
+	}
+}
+
+// GetContainerByName get a storage container with the name
+func (c *ContainerOp) GetContainerByName(ctx context.Context, name string) (*ContainerData, error) {
+	scId, err := c.ResolveContainerID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetContainer(ctx, scId)
+}
+
+// GetContainer get a storage container with scId
+func (c *ContainerOp) GetContainer(ctx context.Context, scId string) (*ContainerData, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "/rest/internal/cloud/containers/"+scId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ContainerData{}
+	if err := c.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CreateContainer create a storage container on a pool
+func (c *ContainerOp) CreateContainer(ctx context.Context, poolId, name string, size uint64, options *ContainerCreateOptions) (*ContainerData, error) {
+	if name == "" {
+		return nil, fmt.Errorf("container name is required")
+	}
+
+	params := url.Values{}
+	params.Add("poolId", poolId)
+	params.Add("name", name)
+	params.Add("sizeMB", strconv.FormatUint(size, 10))
+	if options != nil {
+		if err := options.VolumeDefaults.Validate(); err != nil {
+			return nil, err
+		}
+		options.VolumeDefaults.addParams(params)
+	}
+
+	req, err := c.client.NewRequest(ctx, http.MethodPost, "/rest/internal/cloud/containers", params)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ContainerData{}
+	if err := c.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	c.client.containerNames.invalidate()
+
+	return &res, nil
+}
+
+// DeleteContainer delete an empty storage container
+func (c *ContainerOp) DeleteContainer(ctx context.Context, scId string) error {
+	req, err := c.client.NewRequest(ctx, http.MethodDelete, "/rest/internal/cloud/containers/"+scId, nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := c.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+	c.client.containerNames.invalidate()
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContainer(t *testing.T) {
+	fmt.Println("------------TestContainer--------------")
+	skipIfNoTestConf(t)
+	ctx = context.Background()
+
+	listGetContainerTest(t)
+}
+
+func listGetContainerTest(t *testing.T) {
+	fmt.Println("listGetContainerTest Enter")
+
+	scs, err := testConf.containerOp.ListContainers(ctx)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	for _, sc := range *scs {
+		fmt.Printf("  Container Id:%s, name: %s, size: %s, free: %s\n", sc.ID, sc.Name, sc.Size(), sc.Free())
+	}
+
+	sc, err := testConf.containerOp.GetContainer(ctx, testConf.scId)
+	if err != nil {
+		t.Fatalf("GetContainer failed: %v", err)
+	}
+	fmt.Printf("  Container: %+v\n", *sc)
+
+	fmt.Println("listGetContainerTest Leave")
+}
+
+func TestContainerLifecycle(t *testing.T) {
+	fake := newFakeServer(t)
+	scs := map[string]*ContainerData{}
+	fake.handle(http.MethodPost, "/rest/internal/cloud/containers", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sc := &ContainerData{ID: "sc" + r.Form.Get("name"), Name: r.Form.Get("name"), PoolID: r.Form.Get("poolId"), RaidLevel: "RAID6"}
+		fmt.Sscan(r.Form.Get("sizeMB"), &sc.SizeMB)
+		sc.FreeMB = sc.SizeMB
+		sc.VolumeDefaults = VolumeDefaults{Provision: Provision(r.Form.Get("provision")), Compress: Compression(r.Form.Get("compress")), Dedup: "off"}
+		if r.Form.Get("dedup") != "" {
+			sc.VolumeDefaults.Dedup = r.Form.Get("dedup")
+		}
+		scs[sc.ID] = sc
+
+		path := "/rest/internal/cloud/containers/" + sc.ID
+		fake.handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+			if sc, ok := scs[sc.ID]; ok {
+				writeJSON(w, http.StatusOK, sc)
+				return
+			}
+			writeError(w, http.StatusNotFound, "container not found")
+		})
+		fake.handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request) {
+			delete(scs, sc.ID)
+			writeJSON(w, http.StatusOK, EmptyData{})
+		})
+		writeJSON(w, http.StatusOK, sc)
+	})
+	fake.handle(http.MethodGet, "/rest/internal/cloud/containers", func(w http.ResponseWriter, r *http.Request) {
+		res := []ContainerData{}
+		for _, sc := range scs {
+			res = append(res, *sc)
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+	containerOp := NewContainer(fake.authClient(t))
+	ctx := context.Background()
+
+	options := ContainerCreateOptions{VolumeDefaults: &VolumeCreateOptions{Provision: ProvisionThin, Compress: CompressionLz4, Dedup: true}}
+	sc, err := containerOp.CreateContainer(ctx, "pool1", "k8s", 10240, &options)
+	if err != nil {
+		t.Fatalf("CreateContainer failed: %v", err)
+	}
+	if sc.PoolID != "pool1" || sc.Size() != 10*GiB || sc.VolumeDefaults.Dedup != "on" || sc.VolumeDefaults.Options() != *options.VolumeDefaults {
+		t.Fatalf("unexpected container: %+v", *sc)
+	}
+
+	options.VolumeDefaults.Compress = "zstd"
+	if _, err := containerOp.CreateContainer(ctx, "pool1", "bad", 10240, &options); err == nil {
+		t.Fatalf("CreateContainer with invalid volume defaults expected an error")
+	}
+	if _, err := containerOp.CreateContainer(ctx, "pool1", "", 10240, nil); err == nil {
+		t.Fatalf("CreateContainer without name expected an error")
+	}
+
+	scList, err := containerOp.ListContainers(ctx)
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	if len(*scList) != 1 {
+		t.Fatalf("unexpected containers: %+v", *scList)
+	}
+
+	res, err := containerOp.GetContainer(ctx, sc.ID)
+	if err != nil {
+		t.Fatalf("GetContainer failed: %v", err)
+	}
+	if res.Name != "k8s" || res.Free() != 10*GiB {
+		t.Fatalf("unexpected container: %+v", *res)
+	}
+
+	if err := containerOp.DeleteContainer(ctx, sc.ID); err != nil {
+		t.Fatalf("DeleteContainer failed: %v", err)
+	}
+	if _, err := containerOp.GetContainer(ctx, sc.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetContainer of a deleted container expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolveContainerID(t *testing.T) {
+	fake := newFakeServer(t)
+	scs := []ContainerData{{ID: "sc1", Name: "k8s"}, {ID: "sc2", Name: "backup"}, {ID: "sc3", Name: "backup"}}
+	fake.handle(http.MethodGet, "/rest/internal/cloud/containers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, scs)
+	})
+	fake.handle(http.MethodGet, "/rest/internal/cloud/containers/sc1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, scs[0])
+	})
+	lists := func() int {
+		return fake.count(http.MethodGet, "/rest/internal/cloud/containers")
+	}
+	ctx := context.Background()
+
+	containerOp := NewContainer(fake.authClient(t))
+	for i := 0; i < 3; i++ {
+		scId, err := containerOp.ResolveContainerID(ctx, "k8s")
+		if err != nil {
+			t.Fatalf("ResolveContainerID failed: %v", err)
+		}
+		if scId != "sc1" {
+			t.Fatalf("unexpected container ID: %s", scId)
+		}
+	}
+	if lists() != 1 {
+		t.Fatalf("expected 1 list request with the cache, got %d", lists())
+	}
+
+	sc, err := containerOp.GetContainerByName(ctx, "k8s")
+	if err != nil || sc.ID != "sc1" {
+		t.Fatalf("GetContainerByName failed: %v", err)
+	}
+
+	_, err = containerOp.ResolveContainerID(ctx, "backup")
+	var ambiguousErr *AmbiguousNameError
+	if !errors.As(err, &ambiguousErr) || len(ambiguousErr.IDs) != 2 {
+		t.Fatalf("expected AmbiguousNameError, got %v", err)
+	}
+
+	scs = append(scs, ContainerData{ID: "sc4", Name: "new"})
+	if scId, err := containerOp.ResolveContainerID(ctx, "new"); err != nil || scId != "sc4" {
+		t.Fatalf("ResolveContainerID of a new container failed: %s, %v", scId, err)
+	}
+	if _, err := containerOp.ResolveContainerID(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if lists() != 3 {
+		t.Fatalf("expected unknown names to be fetched again, got %d list requests", lists())
+	}
+
+	// A client without cache lists containers on every resolution
+	client := NewClient(strings.TrimPrefix(fake.URL, "http://"), ClientOptions{NameCacheTTL: -1})
+	authClient, err := client.GetAuthClient(ctx, "admin", "1234")
+	if err != nil {
+		t.Fatalf("GetAuthClient failed: %v", err)
+	}
+	containerOp = NewContainer(authClient)
+	containerOp.ResolveContainerID(ctx, "k8s")
+	containerOp.ResolveContainerID(ctx, "k8s")
+	if lists() != 5 {
+		t.Fatalf("expected 5 list requests without cache, got %d", lists())
+	}
+
+	// An expired cache is filled again
+	client = NewClient(strings.TrimPrefix(fake.URL, "http://"), ClientOptions{NameCacheTTL: 10 * time.Millisecond})
+	authClient, err = client.GetAuthClient(ctx, "admin", "1234")
+	if err != nil {
+		t.Fatalf("GetAuthClient failed: %v", err)
+	}
+	containerOp = NewContainer(authClient)
+	containerOp.ResolveContainerID(ctx, "k8s")
+	containerOp.ResolveContainerID(ctx, "k8s")
+	time.Sleep(20 * time.Millisecond)
+	containerOp.ResolveContainerID(ctx, "k8s")
+	if lists() != 7 {
+		t.Fatalf("expected 7 list requests with an expired cache, got %d", lists())
+	}
+}
+
+func TestContainerVolumeDefaults(t *testing.T) {
+	rawdata := `{"id":"sc1","name":"k8s","volumeDefaults":{"blockSize":16384,"provision":"thin","compress":"lz4","dedup":"off"}}`
+	sc := ContainerData{}
+	if err := json.Unmarshal([]byte(rawdata), &sc); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want := VolumeCreateOptions{BlockSize: 16384, Provision: ProvisionThin, Compress: CompressionLz4}
+	if sc.VolumeDefaults.Options() != want {
+		t.Fatalf("unexpected volume defaults: %+v", sc.VolumeDefaults)
+	}
+
+	sc.VolumeDefaults.Dedup = "on"
+	if !sc.VolumeDefaults.Options().Dedup {
+		t.Fatalf("dedup is not enabled: %+v", sc.VolumeDefaults)
+	}
+}
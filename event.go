@@ -0,0 +1,366 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// EventSeverity is the severity of an event.
+type EventSeverity string
+
+const (
+	EventInfo     EventSeverity = "info"
+	EventWarning  EventSeverity = "warning"
+	EventError    EventSeverity = "error"
+	EventCritical EventSeverity = "critical"
+)
+
+// EventData is an event of the event log.
+type EventData struct {
+	ID       uint64        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Severity EventSeverity `json:"severity"`
+	Category string        `json:"category"` // ex "system", "hardware", "volume" or "dataTransfer"
+	Source   string        `json:"source"`   // the component raising the event, ex "controller 0" or "disk 0:5"
+	Message  string        `json:"message"`
+}
+
+// EventFilter are the optional conditions of ListEvents. Zero values do not filter.
+type EventFilter struct {
+	From       time.Time       // events at or after From
+	To         time.Time       // events before To
+	Severities []EventSeverity // events with one of the severities
+	Categories []string        // events of one of the categories
+	AfterID    uint64          // events with an ID larger than AfterID
+	Offset     int             // number of matching events to skip
+	Limit      int             // maximum number of events, 0: the storage default
+}
+
+// The response data of ListEvents method
+type EventPage struct {
+	Events []EventData `json:"events"`
+	Total  int         `json:"total"` // number of matching events of all pages
+}
+
+// EventCursor is the position of the last seen event for incremental polling with Since.
+// The zero cursor is before the first event.
+type EventCursor uint64
+
+// The number of events fetched per request by Since
+const eventPageSize = 100
+
+func (f *EventFilter) query() string {
+	params := url.Values{}
+	if !f.From.IsZero() {
+		params.Add("from", f.From.UTC().Format(time.RFC3339))
+	}
+	if !f.To.IsZero() {
+		params.Add("to", f.To.UTC().Format(time.RFC3339))
+	}
+	if len(f.Severities) > 0 {
+		severities := make([]string, len(f.Severities))
+		for i, s := range f.Severities {
+			severities[i] = string(s)
+		}
+		params.Add("severity", strings.Join(severities, ","))
+	}
+	if len(f.Categories) > 0 {
+		params.Add("category", strings.Join(f.Categories, ","))
+	}
+	if f.AfterID != 0 {
+		params.Add("afterId", strconv.FormatUint(f.AfterID, 10))
+	}
+	if f.Offset != 0 {
+		params.Add("offset", strconv.Itoa(f.Offset))
+	}
+	if f.Limit != 0 {
+		params.Add("limit", strconv.Itoa(f.Limit))
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+	return "?" + params.Encode()
+}
+
+// ListEvents list the events of the event log matching the filter
+func (s *SystemOp) ListEvents(ctx context.Context, filter EventFilter) (*EventPage, error) {
+	if s.authClient == nil {
+		return nil, ErrAuthRequired
+	}
+
+	req, err := s.authClient.NewRequest(ctx, http.MethodGet, "/rest/v2/system/events"+filter.query(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := EventPage{}
+	if err := s.authClient.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Since returns the events after the cursor in ID order and the cursor of the last returned event.
+// Pass the returned cursor to the next call to poll new events incrementally.
+func (s *SystemOp) Since(ctx context.Context, cursor EventCursor) ([]EventData, EventCursor, error) {
+	events := []EventData{}
+	for {
+		page, err := s.ListEvents(ctx, EventFilter{AfterID: uint64(cursor), Limit: eventPageSize})
+		if err != nil {
+			return events, cursor, err
+		}
+
+		last := cursor
+		sort.Slice(page.Events, func(i, j int) bool {
+			return page.Events[i].ID < page.Events[j].ID
+		})
+		for _, e := range page.Events {
+			if e.ID > uint64(cursor) {
+				events = append(events, e)
+				cursor = EventCursor(e.ID)
+			}
+		}
+
+		if len(page.Events) < eventPageSize || cursor == last {
+			return events, cursor, nil
+		}
+	}
+}
+
+// WatchOptions are the optional settings of WatchEvents. Zero values use the defaults.
+type WatchOptions struct {
+	// Cursor is the last seen event, 0 watches the events raised after WatchEvents is called.
+	// To receive the whole event log, call Since first and pass its cursor.
+	Cursor EventCursor
+
+	PollInterval time.Duration // interval of polling when the storage has no event stream, default 10s
+	MinBackoff   time.Duration // delay before the first reconnect after an error, default 1s
+	MaxBackoff   time.Duration // maximum delay between reconnects, default 1m
+
+	// OnError is called with the errors the watcher recovers from by reconnecting, ex a controller failover.
+	OnError func(error)
+}
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultMinBackoff   = time.Second
+	defaultMaxBackoff   = time.Minute
+)
+
+var errEventStreamClosed = errors.New("event stream closed")
+
+type eventWatcher struct {
+	system     *SystemOp
+	httpClient *http.Client // without timeout, an event stream lasts until it is closed
+	opts       WatchOptions
+	cursor     EventCursor
+	events     chan EventData
+}
+
+// WatchEvents returns a channel receiving the new events in ID order. The events are pushed by the
+// event stream of the storage, or polled every PollInterval when the storage has no event stream.
+// The watcher reconnects with exponential backoff on errors and never delivers an event twice.
+// The channel is closed when ctx is done.
+func (s *SystemOp) WatchEvents(ctx context.Context, opts *WatchOptions) (<-chan EventData, error) {
+	if s.authClient == nil {
+		return nil, ErrAuthRequired
+	}
+
+	w := &eventWatcher{
+		system:     s,
+		httpClient: &http.Client{Transport: s.authClient.HTTPClient.Transport},
+		events:     make(chan EventData),
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.PollInterval <= 0 {
+		w.opts.PollInterval = defaultPollInterval
+	}
+	if w.opts.MinBackoff <= 0 {
+		w.opts.MinBackoff = defaultMinBackoff
+	}
+	if w.opts.MaxBackoff < w.opts.MinBackoff {
+		w.opts.MaxBackoff = defaultMaxBackoff
+		if w.opts.MaxBackoff < w.opts.MinBackoff {
+			w.opts.MaxBackoff = w.opts.MinBackoff
+		}
+	}
+
+	w.cursor = w.opts.Cursor
+	if w.cursor == 0 {
+		cursor, err := s.latestEvent(ctx)
+		if err != nil {
+			return nil, err
+		}
+		w.cursor = cursor
+	}
+
+	go w.run(ctx)
+
+	return w.events, nil
+}
+
+// latestEvent returns the cursor of the newest event.
+func (s *SystemOp) latestEvent(ctx context.Context) (EventCursor, error) {
+	page, err := s.ListEvents(ctx, EventFilter{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	if page.Total > 1 {
+		if page, err = s.ListEvents(ctx, EventFilter{Offset: page.Total - 1, Limit: 1}); err != nil {
+			return 0, err
+		}
+	}
+
+	var cursor EventCursor
+	for _, e := range page.Events {
+		if EventCursor(e.ID) > cursor {
+			cursor = EventCursor(e.ID)
+		}
+	}
+
+	return cursor, nil
+}
+
+func (w *eventWatcher) run(ctx context.Context) {
+	defer close(w.events)
+
+	streaming := true
+	backoff := w.opts.MinBackoff
+	for {
+		var (
+			connected bool
+			err       error
+		)
+		if streaming {
+			connected, err = w.stream(ctx)
+			if errors.Is(err, ErrNotFound) {
+				glog.V(2).Infof("[WatchEvents] no event stream, poll events every %v\n", w.opts.PollInterval)
+				streaming = false
+				continue
+			}
+		} else {
+			connected, err = w.poll(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			backoff = w.opts.MinBackoff
+		}
+		delay := w.opts.PollInterval
+		if err != nil {
+			glog.V(2).Infof("[WatchEvents] reconnect in %v: %v\n", backoff, err)
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+			delay = backoff
+			if backoff *= 2; backoff > w.opts.MaxBackoff {
+				backoff = w.opts.MaxBackoff
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// send delivers an event unless it was delivered before. It returns false when ctx is done.
+func (w *eventWatcher) send(ctx context.Context, e EventData) bool {
+	if EventCursor(e.ID) <= w.cursor {
+		return true
+	}
+
+	select {
+	case w.events <- e:
+		w.cursor = EventCursor(e.ID)
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *eventWatcher) poll(ctx context.Context) (bool, error) {
+	events, _, err := w.system.Since(ctx, w.cursor)
+	for _, e := range events {
+		if !w.send(ctx, e) {
+			return true, ctx.Err()
+		}
+	}
+
+	return err == nil, err
+}
+
+// stream receives the server-sent events after the cursor until the connection is closed.
+// It reports whether the stream was connected, so the backoff is reset.
+func (w *eventWatcher) stream(ctx context.Context) (bool, error) {
+	cursor := strconv.FormatUint(uint64(w.cursor), 10)
+	req, err := w.system.authClient.NewRequest(ctx, http.MethodGet, "/rest/v2/system/events/stream?afterId="+cursor, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", cursor)
+
+	res, err := w.system.authClient.do(ctx, w.httpClient, req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, newAPIError(res)
+	}
+
+	data := []string{}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line dispatches the event
+			if len(data) == 0 {
+				continue
+			}
+			e := EventData{}
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &e); err != nil {
+				return true, fmt.Errorf("invalid event %q: %v", strings.Join(data, "\n"), err)
+			}
+			data = data[:0]
+			if !w.send(ctx, e) {
+				return true, ctx.Err()
+			}
+		case strings.HasPrefix(line, ":"):
+			// comment, ex a keep-alive
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+
+	return true, errEventStreamClosed
+}
@@ -0,0 +1,307 @@
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeEvents serves the event log API on a fake server and filters the events like the storage.
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []EventData
+}
+
+var fakeEventStart = time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func newFakeEvents(fake *fakeServer, n int) *fakeEvents {
+	fe := &fakeEvents{}
+	fe.add(n)
+
+	fake.handle(http.MethodGet, "/rest/v2/system/events", func(w http.ResponseWriter, r *http.Request) {
+		fe.mu.Lock()
+		defer fe.mu.Unlock()
+		q := r.URL.Query()
+		from, _ := time.Parse(time.RFC3339, q.Get("from"))
+		to, _ := time.Parse(time.RFC3339, q.Get("to"))
+		afterId, _ := strconv.ParseUint(q.Get("afterId"), 10, 64)
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		if limit == 0 {
+			limit = 50
+		}
+
+		matched := []EventData{}
+		for _, e := range fe.events {
+			if e.ID <= afterId || (!from.IsZero() && e.Time.Before(from)) || (!to.IsZero() && !e.Time.Before(to)) {
+				continue
+			}
+			if s := q.Get("severity"); s != "" && !strings.Contains(","+s+",", ","+string(e.Severity)+",") {
+				continue
+			}
+			if c := q.Get("category"); c != "" && !strings.Contains(","+c+",", ","+e.Category+",") {
+				continue
+			}
+			matched = append(matched, e)
+		}
+
+		page := EventPage{Events: []EventData{}, Total: len(matched)}
+		for i := offset; i < len(matched) && i < offset+limit; i++ {
+			page.Events = append(page.Events, matched[i])
+		}
+		writeJSON(w, http.StatusOK, page)
+	})
+
+	return fe
+}
+
+// add appends n events, one per minute, with rotating severities and categories.
+func (fe *fakeEvents) add(n int) {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+
+	severities := []EventSeverity{EventInfo, EventInfo, EventWarning, EventError, EventCritical}
+	categories := []string{"system", "hardware", "volume"}
+	for i := 0; i < n; i++ {
+		id := uint64(len(fe.events) + 1)
+		fe.events = append(fe.events, EventData{
+			ID:       id,
+			Time:     fakeEventStart.Add(time.Duration(id) * time.Minute),
+			Severity: severities[int(id)%len(severities)],
+			Category: categories[int(id)%len(categories)],
+			Source:   "controller 0",
+			Message:  fmt.Sprintf("event %d", id),
+		})
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeEvents(fake, 30)
+	ctx := context.Background()
+
+	if _, err := NewSystem(fake.client()).ListEvents(ctx, EventFilter{}); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("ListEvents without authentication expected ErrAuthRequired, got %v", err)
+	}
+
+	systemOp := NewAuthSystem(fake.authClient(t))
+	page, err := systemOp.ListEvents(ctx, EventFilter{})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if page.Total != 30 || len(page.Events) != 30 || page.Events[0].Message != "event 1" || !page.Events[0].Time.Equal(fakeEventStart.Add(time.Minute)) {
+		t.Fatalf("unexpected events: %+v", *page)
+	}
+
+	filter := EventFilter{
+		From:       fakeEventStart.Add(10 * time.Minute),
+		To:         fakeEventStart.Add(25 * time.Minute),
+		Severities: []EventSeverity{EventError, EventCritical},
+	}
+	page, err = systemOp.ListEvents(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	for _, e := range page.Events {
+		if e.ID < 10 || e.ID >= 25 || (e.Severity != EventError && e.Severity != EventCritical) {
+			t.Fatalf("event does not match the filter: %+v", e)
+		}
+	}
+	if page.Total != 6 {
+		t.Fatalf("expected 6 events, got %d", page.Total)
+	}
+
+	page, err = systemOp.ListEvents(ctx, EventFilter{Categories: []string{"hardware"}, Offset: 2, Limit: 3})
+	if err != nil {
+		t.Fatalf("ListEvents failed: %v", err)
+	}
+	if page.Total != 10 || len(page.Events) != 3 || page.Events[0].ID != 7 {
+		t.Fatalf("unexpected page: %+v", *page)
+	}
+}
+
+func TestEventsSince(t *testing.T) {
+	fake := newFakeServer(t)
+	fe := newFakeEvents(fake, 250)
+	systemOp := NewAuthSystem(fake.authClient(t))
+	ctx := context.Background()
+
+	events, cursor, err := systemOp.Since(ctx, 0)
+	if err != nil {
+		t.Fatalf("Since failed: %v", err)
+	}
+	if len(events) != 250 || cursor != 250 || events[249].ID != 250 {
+		t.Fatalf("unexpected events: %d, cursor %d", len(events), cursor)
+	}
+	if n := fake.count(http.MethodGet, "/rest/v2/system/events"); n != 3 {
+		t.Fatalf("expected 3 pages, got %d", n)
+	}
+
+	events, cursor, err = systemOp.Since(ctx, cursor)
+	if err != nil || len(events) != 0 || cursor != 250 {
+		t.Fatalf("expected no new events: %d, cursor %d, %v", len(events), cursor, err)
+	}
+
+	fe.add(5)
+	events, cursor, err = systemOp.Since(ctx, cursor)
+	if err != nil {
+		t.Fatalf("Since failed: %v", err)
+	}
+	if len(events) != 5 || events[0].ID != 251 || cursor != 255 {
+		t.Fatalf("unexpected new events: %+v, cursor %d", events, cursor)
+	}
+}
+
+// writeSSE writes events in the server-sent events format and flushes them to the client.
+func writeSSE(w http.ResponseWriter, events ...EventData) {
+	for _, e := range events {
+		data, _ := json.Marshal(e)
+		fmt.Fprintf(w, ": keep-alive\nid: %d\ndata: %s\n\n", e.ID, data)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func receiveEvents(t *testing.T, ch <-chan EventData, n int) []uint64 {
+	ids := []uint64{}
+	for len(ids) < n {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after events %v", ids)
+			}
+			ids = append(ids, e.ID)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout after events %v", ids)
+		}
+	}
+	return ids
+}
+
+func waitClosed(t *testing.T, ch <-chan EventData) {
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("channel not closed after cancel")
+		}
+	}
+}
+
+func TestWatchEventsStream(t *testing.T) {
+	fake := newFakeServer(t)
+	fe := newFakeEvents(fake, 7)
+
+	var (
+		mu      sync.Mutex
+		afterId []string
+		errs    []error
+	)
+	fake.handle(http.MethodGet, "/rest/v2/system/events/stream", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		afterId = append(afterId, r.URL.Query().Get("afterId"))
+		conn := len(afterId)
+		mu.Unlock()
+
+		fe.mu.Lock()
+		events := append([]EventData{}, fe.events...)
+		fe.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch conn {
+		case 1, 2:
+			// controller failover
+			writeError(w, http.StatusServiceUnavailable, "controller is not ready")
+		case 3:
+			// replays a seen event, then the connection drops
+			writeSSE(w, events[2:5]...)
+		default:
+			writeSSE(w, events[4:]...)
+			<-r.Context().Done()
+		}
+	})
+	systemOp := NewAuthSystem(fake.authClient(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := systemOp.WatchEvents(ctx, &WatchOptions{
+		Cursor:     3,
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 2 * time.Millisecond,
+		OnError: func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+
+	ids := receiveEvents(t, ch, 4)
+	if fmt.Sprint(ids) != "[4 5 6 7]" {
+		t.Fatalf("unexpected events %v", ids)
+	}
+
+	cancel()
+	waitClosed(t, ch)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(afterId) != "[3 3 3 5]" {
+		t.Fatalf("unexpected afterId of the connections: %v", afterId)
+	}
+	if len(errs) != 3 || !errors.Is(errs[2], errEventStreamClosed) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	apiErr := &APIError{}
+	if !errors.As(errs[0], &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected error: %v", errs[0])
+	}
+}
+
+func TestWatchEventsPolling(t *testing.T) {
+	fake := newFakeServer(t)
+	fe := newFakeEvents(fake, 120)
+	systemOp := NewAuthSystem(fake.authClient(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := systemOp.WatchEvents(ctx, &WatchOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WatchEvents failed: %v", err)
+	}
+
+	// more new events than a page
+	fe.add(150)
+	ids := receiveEvents(t, ch, 150)
+	for i, id := range ids {
+		if id != uint64(121+i) {
+			t.Fatalf("unexpected events %v", ids)
+		}
+	}
+	fe.add(2)
+	ids = receiveEvents(t, ch, 2)
+	if fmt.Sprint(ids) != "[271 272]" {
+		t.Fatalf("unexpected events %v", ids)
+	}
+	if n := fake.count(http.MethodGet, "/rest/v2/system/events/stream"); n != 1 {
+		t.Fatalf("expected one event stream request, got %d", n)
+	}
+
+	cancel()
+	waitClosed(t, ch)
+
+	if _, err := NewSystem(fake.client()).WatchEvents(ctx, nil); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// FirmwareOp handles firmware related methods of the QSM storage.
+type FirmwareOp struct {
+	client *AuthClient
+}
+
+// ControllerFirmware is the firmware running on a controller.
+type ControllerFirmware struct {
+	Controller int    `json:"controller"` // controller index, ex 0 or 1
+	Version    string `json:"version"`
+	BuildDate  string `json:"buildDate"`
+}
+
+// FirmwarePackage is a firmware image uploaded to the storage and ready to be installed.
+type FirmwarePackage struct {
+	ID         string    `json:"id"`
+	Version    string    `json:"version"`
+	FileName   string    `json:"fileName"`
+	Size       uint64    `json:"size"` // in bytes
+	UploadTime time.Time `json:"uploadTime"`
+}
+
+// The response data of GetFirmware method
+type FirmwareData struct {
+	Controllers []ControllerFirmware `json:"controllers"`
+	Packages    []FirmwarePackage    `json:"packages"`
+}
+
+// UpgradeState is the state of a firmware upgrade.
+type UpgradeState string
+
+const (
+	UpgradeIdle      UpgradeState = "idle" // no upgrade was started
+	UpgradePending   UpgradeState = "pending"
+	UpgradeInstall   UpgradeState = "installing"
+	UpgradeReboot    UpgradeState = "rebooting"
+	UpgradeCompleted UpgradeState = "completed"
+	UpgradeFailed    UpgradeState = "failed"
+)
+
+// ControllerUpgrade is the upgrade progress of a controller. The controllers are upgraded one by one,
+// so the other controller keeps serving I/O while a controller reboots.
+type ControllerUpgrade struct {
+	Controller int          `json:"controller"`
+	State      UpgradeState `json:"state"`
+	Percent    int          `json:"percent"`
+}
+
+// The response data of StartUpgrade and GetUpgradeStatus methods
+type UpgradeStatus struct {
+	State       UpgradeState        `json:"state"`
+	PackageID   string              `json:"packageId"`
+	Version     string              `json:"version"` // the version being installed
+	Controllers []ControllerUpgrade `json:"controllers"`
+	Message     string              `json:"message"` // the reason of a failed upgrade
+}
+
+// UploadProgress is called while a firmware image is uploaded with the bytes sent so far
+// and the total size, which is -1 when unknown.
+type UploadProgress func(sent, total int64)
+
+// progressReader reports the bytes read from a reader.
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress UploadProgress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.progress(p.sent, p.total)
+	}
+	return n, err
+}
+
+// NewFirmware returns firmware operation
+func NewFirmware(client *AuthClient) *FirmwareOp {
+	return &FirmwareOp{client}
+}
+
+// GetFirmware get the firmware versions of the controllers and the uploaded firmware packages
+func (f *FirmwareOp) GetFirmware(ctx context.Context) (*FirmwareData, error) {
+	req, err := f.client.NewRequest(ctx, http.MethodGet, "/rest/v2/system/firmware", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := FirmwareData{}
+	if err := f.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UploadFirmware upload a firmware image read from r. The size in bytes is -1 when unknown.
+// The optional progress callback is called while the image is sent.
+func (f *FirmwareOp) UploadFirmware(ctx context.Context, fileName string, r io.Reader, size int64, progress UploadProgress) (*FirmwarePackage, error) {
+	if fileName == "" {
+		return nil, fmt.Errorf("firmware file name is required")
+	}
+
+	body := r
+	if progress != nil {
+		body = &progressReader{r: r, total: size, progress: progress}
+	}
+
+	req, err := f.client.NewRequest(ctx, http.MethodPost, "/rest/v2/system/firmware/packages?fileName="+url.QueryEscape(fileName), body)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 {
+		req.ContentLength = size
+	}
+
+	res := FirmwarePackage{}
+	if err := f.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// DeleteFirmwarePackage delete an uploaded firmware package
+func (f *FirmwareOp) DeleteFirmwarePackage(ctx context.Context, packageId string) error {
+	req, err := f.client.NewRequest(ctx, http.MethodDelete, "/rest/v2/system/firmware/packages/"+packageId, nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := f.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StartUpgrade start a rolling upgrade of the controllers to an uploaded firmware package
+func (f *FirmwareOp) StartUpgrade(ctx context.Context, packageId string) (*UpgradeStatus, error) {
+	if packageId == "" {
+		return nil, fmt.Errorf("firmware package id is required")
+	}
+
+	rawdata, _ := json.Marshal(map[string]string{"packageId": packageId})
+	req, err := f.client.NewRequest(ctx, http.MethodPost, "/rest/v2/system/firmware/upgrade", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := UpgradeStatus{}
+	if err := f.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetUpgradeStatus get the status of the last firmware upgrade
+func (f *FirmwareOp) GetUpgradeStatus(ctx context.Context) (*UpgradeStatus, error) {
+	req, err := f.client.NewRequest(ctx, http.MethodGet, "/rest/v2/system/firmware/upgrade", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := UpgradeStatus{}
+	if err := f.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// rebootError reports whether an error is expected while a controller reboots, a refused, reset or
+// dropped connection, an unreachable host, a timeout or a server error like service unavailable of
+// the failover.
+func rebootError(err error) bool {
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	for _, target := range []error{io.EOF, io.ErrUnexpectedEOF, syscall.ECONNREFUSED, syscall.ECONNRESET,
+		syscall.EHOSTUNREACH, syscall.ENETUNREACH} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	netErr := net.Error(nil)
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// The polling interval of WaitUpgrade when none is given
+const defaultUpgradePollInterval = 10 * time.Second
+
+// WaitUpgrade polls the upgrade status every interval until the upgrade is completed. The errors of
+// the storage while a controller reboots are tolerated. The optional progress callback receives every
+// polled status. A non-positive interval polls every 10 seconds. An error is returned if the upgrade
+// failed or ctx is done.
+func (f *FirmwareOp) WaitUpgrade(ctx context.Context, interval time.Duration, progress func(*UpgradeStatus)) (*UpgradeStatus, error) {
+	if interval <= 0 {
+		interval = defaultUpgradePollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var status *UpgradeStatus
+	for {
+		s, err := f.GetUpgradeStatus(ctx)
+		switch {
+		case err == nil:
+			status = s
+			if progress != nil {
+				progress(status)
+			}
+			switch status.State {
+			case UpgradeCompleted:
+				return status, nil
+			case UpgradeFailed:
+				return status, fmt.Errorf("firmware upgrade to %s failed: %s", status.Version, status.Message)
+			case UpgradeIdle:
+				return status, fmt.Errorf("no firmware upgrade in progress")
+			}
+		case ctx.Err() == nil && rebootError(err):
+			glog.V(2).Infof("[WaitUpgrade] storage is not available: %v\n", err)
+		default:
+			return status, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
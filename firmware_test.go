@@ -0,0 +1,338 @@
+package goqsm
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeFirmware serves the firmware API on a fake server. An upgrade advances one step per status
+// request, and the storage is not available while a controller reboots like a real failover.
+type fakeFirmware struct {
+	mu       sync.Mutex
+	firmware FirmwareData
+	images   map[string][]byte
+	status   UpgradeStatus
+	steps    []func(w http.ResponseWriter) bool // returns false when the request failed
+}
+
+func newFakeFirmware(fake *fakeServer) *fakeFirmware {
+	ff := &fakeFirmware{
+		firmware: FirmwareData{
+			Controllers: []ControllerFirmware{{Controller: 0, Version: "3.2.0"}, {Controller: 1, Version: "3.2.0"}},
+			Packages:    []FirmwarePackage{},
+		},
+		images: map[string][]byte{},
+		status: UpgradeStatus{State: UpgradeIdle},
+	}
+
+	fake.handle(http.MethodGet, "/rest/v2/system/firmware", func(w http.ResponseWriter, r *http.Request) {
+		ff.mu.Lock()
+		defer ff.mu.Unlock()
+		writeJSON(w, http.StatusOK, ff.firmware)
+	})
+	fake.handle(http.MethodPost, "/rest/v2/system/firmware/packages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/octet-stream" {
+			writeError(w, http.StatusBadRequest, "unexpected content type "+r.Header.Get("Content-Type"))
+			return
+		}
+		image, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ff.mu.Lock()
+		defer ff.mu.Unlock()
+		// the version is in the image header, ex "QSM 3.3.0\n..."
+		version := strings.TrimPrefix(strings.SplitN(string(image), "\n", 2)[0], "QSM ")
+		pkg := FirmwarePackage{ID: "pkg-" + version, Version: version, FileName: r.URL.Query().Get("fileName"), Size: uint64(len(image))}
+		ff.images[pkg.ID] = image
+		ff.firmware.Packages = append(ff.firmware.Packages, pkg)
+		writeJSON(w, http.StatusOK, pkg)
+	})
+	fake.handle(http.MethodPost, "/rest/v2/system/firmware/upgrade", func(w http.ResponseWriter, r *http.Request) {
+		param := struct {
+			PackageID string `json:"packageId"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&param)
+
+		ff.mu.Lock()
+		defer ff.mu.Unlock()
+		image, ok := ff.images[param.PackageID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "package not found")
+			return
+		}
+		version := strings.TrimPrefix(param.PackageID, "pkg-")
+		ff.status = UpgradeStatus{
+			State:       UpgradePending,
+			PackageID:   param.PackageID,
+			Version:     version,
+			Controllers: []ControllerUpgrade{{Controller: 0, State: UpgradePending}, {Controller: 1, State: UpgradePending}},
+		}
+		ff.steps = ff.upgradeSteps(version, bytes.Contains(image, []byte("corrupted")))
+		writeJSON(w, http.StatusOK, ff.status)
+	})
+	fake.handle(http.MethodGet, "/rest/v2/system/firmware/upgrade", func(w http.ResponseWriter, r *http.Request) {
+		ff.mu.Lock()
+		defer ff.mu.Unlock()
+		if len(ff.steps) > 0 {
+			step := ff.steps[0]
+			ff.steps = ff.steps[1:]
+			if !step(w) {
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, ff.status)
+	})
+
+	return ff
+}
+
+// upgradeSteps returns the steps of a rolling upgrade, one controller after the other.
+func (ff *fakeFirmware) upgradeSteps(version string, corrupted bool) []func(w http.ResponseWriter) bool {
+	install := func(ctrl, percent int) func(w http.ResponseWriter) bool {
+		return func(w http.ResponseWriter) bool {
+			ff.status.State = UpgradeInstall
+			ff.status.Controllers[ctrl].State = UpgradeInstall
+			ff.status.Controllers[ctrl].Percent = percent
+			return true
+		}
+	}
+	reboot := func(ctrl int) func(w http.ResponseWriter) bool {
+		return func(w http.ResponseWriter) bool {
+			ff.status.State = UpgradeReboot
+			ff.status.Controllers[ctrl].State = UpgradeReboot
+			return true
+		}
+	}
+	booted := func(ctrl int) func(w http.ResponseWriter) bool {
+		return func(w http.ResponseWriter) bool {
+			ff.status.Controllers[ctrl].State = UpgradeCompleted
+			ff.status.Controllers[ctrl].Percent = 100
+			ff.firmware.Controllers[ctrl].Version = version
+			if ctrl == len(ff.status.Controllers)-1 {
+				ff.status.State = UpgradeCompleted
+			}
+			return true
+		}
+	}
+	// the connection drops when the management port moves to the other controller
+	dropConnection := func(w http.ResponseWriter) bool {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return false
+	}
+	unavailable := func(w http.ResponseWriter) bool {
+		writeError(w, http.StatusServiceUnavailable, "controller is not ready")
+		return false
+	}
+
+	if corrupted {
+		return []func(w http.ResponseWriter) bool{
+			install(0, 30),
+			func(w http.ResponseWriter) bool {
+				ff.status.State = UpgradeFailed
+				ff.status.Controllers[0].State = UpgradeFailed
+				ff.status.Message = "image checksum mismatch"
+				return true
+			},
+		}
+	}
+
+	return []func(w http.ResponseWriter) bool{
+		install(0, 50), reboot(0), dropConnection, unavailable, booted(0),
+		install(1, 50), reboot(1), unavailable, booted(1),
+	}
+}
+
+func TestUploadFirmware(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeFirmware(fake)
+	firmwareOp := NewFirmware(fake.authClient(t))
+
+	image := "QSM 3.3.0\n" + strings.Repeat("x", 100000)
+	var sent, total int64
+	progress := func(s, n int64) {
+		sent, total = s, n
+	}
+	pkg, err := firmwareOp.UploadFirmware(context.Background(), "qsm-3.3.0.bin", strings.NewReader(image), int64(len(image)), progress)
+	if err != nil {
+		t.Fatalf("UploadFirmware failed: %v", err)
+	}
+	if pkg.ID != "pkg-3.3.0" || pkg.FileName != "qsm-3.3.0.bin" || pkg.Size != uint64(len(image)) {
+		t.Fatalf("unexpected package: %+v", *pkg)
+	}
+	if sent != int64(len(image)) || total != int64(len(image)) {
+		t.Fatalf("unexpected progress %d/%d", sent, total)
+	}
+
+	// size unknown, ex a download stream
+	if _, err := firmwareOp.UploadFirmware(context.Background(), "qsm-3.4.0.bin", io.MultiReader(strings.NewReader("QSM 3.4.0\n")), -1, progress); err != nil {
+		t.Fatalf("UploadFirmware with unknown size failed: %v", err)
+	}
+	if total != -1 {
+		t.Fatalf("unexpected progress total %d", total)
+	}
+
+	res, err := firmwareOp.GetFirmware(context.Background())
+	if err != nil {
+		t.Fatalf("GetFirmware failed: %v", err)
+	}
+	if len(res.Controllers) != 2 || len(res.Packages) != 2 || res.Packages[1].Version != "3.4.0" {
+		t.Fatalf("unexpected firmware: %+v", *res)
+	}
+}
+
+// newFreshConnClient returns an auth client that opens a connection per request. The transport
+// retries a GET on a dropped keep-alive connection, so only a fresh connection passes the drop to
+// the caller.
+func newFreshConnClient(t *testing.T, fake *fakeServer) *AuthClient {
+	authClient := fake.authClient(t)
+	authClient.HTTPClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+	return authClient
+}
+
+func TestFirmwareUpgrade(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeFirmware(fake)
+	firmwareOp := NewFirmware(newFreshConnClient(t, fake))
+	ctx := context.Background()
+
+	if _, err := firmwareOp.WaitUpgrade(ctx, time.Millisecond, nil); err == nil {
+		t.Fatalf("expected an error without an upgrade")
+	}
+
+	pkg, err := firmwareOp.UploadFirmware(ctx, "qsm-3.3.0.bin", strings.NewReader("QSM 3.3.0\nimage"), -1, nil)
+	if err != nil {
+		t.Fatalf("UploadFirmware failed: %v", err)
+	}
+	status, err := firmwareOp.StartUpgrade(ctx, pkg.ID)
+	if err != nil {
+		t.Fatalf("StartUpgrade failed: %v", err)
+	}
+	if status.State != UpgradePending || status.Version != "3.3.0" {
+		t.Fatalf("unexpected status: %+v", *status)
+	}
+
+	states := []UpgradeState{}
+	status, err = firmwareOp.WaitUpgrade(ctx, time.Millisecond, func(s *UpgradeStatus) {
+		states = append(states, s.State)
+	})
+	if err != nil {
+		t.Fatalf("WaitUpgrade failed: %v", err)
+	}
+	if status.State != UpgradeCompleted || len(states) != 6 || states[1] != UpgradeReboot {
+		t.Fatalf("unexpected upgrade: %+v, states %v", *status, states)
+	}
+
+	res, err := firmwareOp.GetFirmware(ctx)
+	if err != nil {
+		t.Fatalf("GetFirmware failed: %v", err)
+	}
+	for _, c := range res.Controllers {
+		if c.Version != "3.3.0" {
+			t.Fatalf("controller %d runs %s after the upgrade", c.Controller, c.Version)
+		}
+	}
+}
+
+func TestFirmwareUpgradeFailed(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeFirmware(fake)
+	firmwareOp := NewFirmware(fake.authClient(t))
+	ctx := context.Background()
+
+	pkg, err := firmwareOp.UploadFirmware(ctx, "qsm-3.3.0.bin", strings.NewReader("QSM 3.3.0\ncorrupted"), -1, nil)
+	if err != nil {
+		t.Fatalf("UploadFirmware failed: %v", err)
+	}
+	if _, err := firmwareOp.StartUpgrade(ctx, pkg.ID); err != nil {
+		t.Fatalf("StartUpgrade failed: %v", err)
+	}
+
+	status, err := firmwareOp.WaitUpgrade(ctx, time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") || status.State != UpgradeFailed {
+		t.Fatalf("expected a failed upgrade, got %v", err)
+	}
+
+	if _, err := firmwareOp.StartUpgrade(ctx, "pkg-9.9.9"); err == nil {
+		t.Fatalf("expected an error for an unknown package")
+	}
+}
+
+func TestRebootError(t *testing.T) {
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://192.168.1.1/rest/v2/system/firmware/upgrade", Err: err}
+	}
+	dialErr := func(errno syscall.Errno) error {
+		return urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", errno)})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", dialErr(syscall.ECONNREFUSED), true},
+		{"connection reset", dialErr(syscall.ECONNRESET), true},
+		{"connection closed", urlErr(io.EOF), true},
+		{"response cut off", urlErr(io.ErrUnexpectedEOF), true},
+		{"host unreachable", dialErr(syscall.EHOSTUNREACH), true},
+		{"network unreachable", dialErr(syscall.ENETUNREACH), true},
+		{"timeout", urlErr(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), true},
+		{"service unavailable", &APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"not found", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"unknown host", urlErr(&net.DNSError{Err: "no such host", Name: "qsm.invalid", IsNotFound: true}), false},
+		{"certificate", urlErr(x509.UnknownAuthorityError{}), false},
+		{"malformed URL", urlErr(url.EscapeError("%zz")), false},
+	}
+	for _, tt := range tests {
+		if got := rebootError(tt.err); got != tt.want {
+			t.Errorf("rebootError(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRebootErrorDroppedConnection(t *testing.T) {
+	fake := newFakeServer(t)
+	fake.handle(http.MethodGet, "/rest/v2/system/firmware/upgrade", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	})
+
+	_, err := NewFirmware(newFreshConnClient(t, fake)).GetUpgradeStatus(context.Background())
+	if err == nil || !rebootError(err) {
+		t.Fatalf("expected a reboot error for a dropped connection, got %v", err)
+	}
+}
+
+func TestWaitUpgradeBadEndpoint(t *testing.T) {
+	// a malformed address fails at once instead of polling until ctx is done
+	fake := newFakeServer(t)
+	authClient := fake.authClient(t)
+	authClient.baseURL = "http://qsm:port"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := NewFirmware(authClient).WaitUpgrade(ctx, 0, nil); err == nil || ctx.Err() != nil {
+		t.Fatalf("expected an error before ctx is done, got %v", err)
+	}
+}
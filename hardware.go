@@ -0,0 +1,151 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"net/http"
+)
+
+// HardwareOp handles enclosure and disk related methods of the QSM storage.
+type HardwareOp struct {
+	client *AuthClient
+}
+
+// MediaType is the media type of a disk.
+type MediaType string
+
+const (
+	MediaHDD  MediaType = "HDD"
+	MediaSSD  MediaType = "SSD"
+	MediaNVMe MediaType = "NVMe"
+)
+
+// DiskUsage is how a disk is used by the storage.
+type DiskUsage string
+
+const (
+	DiskFree   DiskUsage = "free"   // not used by any pool
+	DiskMember DiskUsage = "member" // member of a pool
+	DiskSpare  DiskUsage = "spare"  // hot spare
+)
+
+// The response data of ListEnclosures method
+type EnclosureData struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Serial    string       `json:"serial"`
+	SlotCount int          `json:"slotCount"`
+	Status    HealthStatus `json:"status"`
+}
+
+// SmartSummary is the summary of the S.M.A.R.T. data of a disk.
+type SmartSummary struct {
+	Status             HealthStatus `json:"status"`
+	PowerOnHours       uint64       `json:"powerOnHours"`
+	ReallocatedSectors uint64       `json:"reallocatedSectors"`
+	PendingSectors     uint64       `json:"pendingSectors"`
+	Temperature        int          `json:"temperature"` // Celsius
+	WearLevel          int          `json:"wearLevel"`   // remaining life in percent of SSD and NVMe disks
+}
+
+// The response data of disk related methods, ex ListDisks and GetDisk.
+type DiskData struct {
+	ID          string       `json:"id"`
+	EnclosureID string       `json:"enclosureId"`
+	Slot        int          `json:"slot"`
+	Model       string       `json:"model"`
+	Serial      string       `json:"serial"`
+	Firmware    string       `json:"firmware"`
+	SizeMB      uint64       `json:"sizeMB"`
+	MediaType   MediaType    `json:"mediaType"`
+	Health      HealthStatus `json:"health"`
+	Smart       SmartSummary `json:"smart"`
+	Usage       DiskUsage    `json:"usage"`
+	PoolID      string       `json:"poolId"` // the pool of a member or a spare disk
+}
+
+// Size returns the size of the disk.
+func (d *DiskData) Size() Capacity {
+	return CapacityFromMB(d.SizeMB)
+}
+
+// Failed reports whether the disk failed or its S.M.A.R.T. status is critical.
+func (d *DiskData) Failed() bool {
+	return d.Health == HealthCritical || d.Smart.Status == HealthCritical
+}
+
+// FailedDisks returns the failed disks, ex for opening replacement tickets.
+func FailedDisks(disks []DiskData) []DiskData {
+	failed := []DiskData{}
+	for _, d := range disks {
+		if d.Failed() {
+			failed = append(failed, d)
+		}
+	}
+
+	return failed
+}
+
+// FreeCapacityByMedia returns the total size of the free disks per media type, ex for capacity planning.
+func FreeCapacityByMedia(disks []DiskData) map[MediaType]Capacity {
+	free := map[MediaType]Capacity{}
+	for _, d := range disks {
+		if d.Usage == DiskFree && !d.Failed() {
+			free[d.MediaType] += d.Size()
+		}
+	}
+
+	return free
+}
+
+// NewHardware returns hardware operation
+func NewHardware(client *AuthClient) *HardwareOp {
+	return &HardwareOp{client}
+}
+
+// ListEnclosures list all enclosures, the head unit and the expansion units
+func (h *HardwareOp) ListEnclosures(ctx context.Context) (*[]EnclosureData, error) {
+	req, err := h.client.NewRequest(ctx, http.MethodGet, "/rest/v2/hardware/enclosures", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []EnclosureData{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ListDisks list the disks of all enclosures
+func (h *HardwareOp) ListDisks(ctx context.Context) (*[]DiskData, error) {
+	req, err := h.client.NewRequest(ctx, http.MethodGet, "/rest/v2/hardware/disks", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []DiskData{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetDisk get a disk with diskId
+func (h *HardwareOp) GetDisk(ctx context.Context, diskId string) (*DiskData, error) {
+	req, err := h.client.NewRequest(ctx, http.MethodGet, "/rest/v2/hardware/disks/"+diskId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := DiskData{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
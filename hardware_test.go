@@ -0,0 +1,64 @@
+package goqsm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHardware(t *testing.T) {
+	fake := newFakeServer(t)
+	enclosures := []EnclosureData{{ID: "enc0", Name: "Head unit", Model: "XS5224", SlotCount: 24, Status: HealthOK}}
+	disks := []DiskData{
+		{ID: "d0", EnclosureID: "enc0", Slot: 1, MediaType: MediaHDD, SizeMB: 4 * 1024 * 1024, Health: HealthOK, Usage: DiskMember, PoolID: "pool1"},
+		{ID: "d1", EnclosureID: "enc0", Slot: 2, MediaType: MediaHDD, SizeMB: 4 * 1024 * 1024, Health: HealthCritical, Usage: DiskMember, PoolID: "pool1"},
+		{ID: "d2", EnclosureID: "enc0", Slot: 3, MediaType: MediaSSD, SizeMB: 1024 * 1024, Health: HealthOK, Usage: DiskFree},
+		{ID: "d3", EnclosureID: "enc0", Slot: 4, MediaType: MediaSSD, SizeMB: 1024 * 1024, Health: HealthOK, Usage: DiskFree, Smart: SmartSummary{Status: HealthCritical, WearLevel: 2}},
+		{ID: "d4", EnclosureID: "enc0", Slot: 5, MediaType: MediaNVMe, SizeMB: 2 * 1024 * 1024, Health: HealthOK, Usage: DiskFree},
+		{ID: "d5", EnclosureID: "enc0", Slot: 6, MediaType: MediaHDD, SizeMB: 4 * 1024 * 1024, Health: HealthOK, Usage: DiskSpare, PoolID: "pool1"},
+	}
+	fake.handleJSON(http.MethodGet, "/rest/v2/hardware/enclosures", enclosures)
+	fake.handleJSON(http.MethodGet, "/rest/v2/hardware/disks", disks)
+	fake.handleJSON(http.MethodGet, "/rest/v2/hardware/disks/d1", disks[1])
+	hardwareOp := NewHardware(fake.authClient(t))
+	ctx := context.Background()
+
+	encs, err := hardwareOp.ListEnclosures(ctx)
+	if err != nil {
+		t.Fatalf("ListEnclosures failed: %v", err)
+	}
+	if !reflect.DeepEqual(*encs, enclosures) {
+		t.Fatalf("unexpected enclosures: %+v", *encs)
+	}
+
+	res, err := hardwareOp.ListDisks(ctx)
+	if err != nil {
+		t.Fatalf("ListDisks failed: %v", err)
+	}
+	if !reflect.DeepEqual(*res, disks) {
+		t.Fatalf("unexpected disks: %+v", *res)
+	}
+
+	failed := FailedDisks(*res)
+	if len(failed) != 2 || failed[0].ID != "d1" || failed[1].ID != "d3" {
+		t.Fatalf("unexpected failed disks: %+v", failed)
+	}
+
+	free := FreeCapacityByMedia(*res)
+	if len(free) != 2 || free[MediaSSD] != TiB || free[MediaNVMe] != 2*TiB {
+		t.Fatalf("unexpected free capacity: %v", free)
+	}
+
+	disk, err := hardwareOp.GetDisk(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetDisk failed: %v", err)
+	}
+	if !disk.Failed() || disk.Size() != 4*TiB || disk.PoolID != "pool1" {
+		t.Fatalf("unexpected disk: %+v", *disk)
+	}
+	if _, err := hardwareOp.GetDisk(ctx, "d9"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetDisk of an unknown disk expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// HostGroupOp handles host group related methods of a target on the QSM storage.
+type HostGroupOp struct {
+	client *AuthClient
+}
+
+// AnyInitiator allows all initiators to access a target.
+const AnyInitiator = "*"
+
+var (
+	iqnRegexp  = regexp.MustCompile(`^iqn\.\d{4}-\d{2}\.[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*(:.+)?$`)
+	euiRegexp  = regexp.MustCompile(`^eui\.[0-9a-f]{16}$`)
+	naaRegexp  = regexp.MustCompile(`^naa\.([0-9a-f]{16}|[0-9a-f]{32})$`)
+	wwpnRegexp = regexp.MustCompile(`^([0-9a-f]{16}|([0-9a-f]{2}:){7}[0-9a-f]{2})$`)
+)
+
+// NewHostGroup returns host group operation
+func NewHostGroup(client *AuthClient) *HostGroupOp {
+	return &HostGroupOp{client}
+}
+
+// NewHost returns a host with the given initiator names
+func NewHost(initiators ...string) Host {
+	return Host{Name: initiators}
+}
+
+// ValidateInitiator checks an initiator name, which is an iSCSI name (iqn., eui. or naa.),
+// a FC WWPN like "21000024ff4c8f4a" or "21:00:00:24:ff:4c:8f:4a", or AnyInitiator.
+func ValidateInitiator(name string) error {
+	if name == AnyInitiator {
+		return nil
+	}
+
+	n := strings.ToLower(name)
+	if iqnRegexp.MatchString(n) || euiRegexp.MatchString(n) || naaRegexp.MatchString(n) || wwpnRegexp.MatchString(n) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid initiator %q", name)
+}
+
+func validateHosts(hosts []Host) error {
+	for _, h := range hosts {
+		if len(h.Name) == 0 {
+			return fmt.Errorf("host without initiator")
+		}
+		for _, name := range h.Name {
+			if err := ValidateInitiator(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hostGroupPath(tgtId, name string) string {
+	return "/rest/v2/dataTransfer/targets/" + tgtId + "/hostGroups/" + url.PathEscape(name)
+}
+
+// ListHostGroups list all host groups of a target
+func (h *HostGroupOp) ListHostGroups(ctx context.Context, tgtId string) (*[]HostGroup, error) {
+	req, err := h.client.NewRequest(ctx, http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgtId+"/hostGroups", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []HostGroup{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetHostGroup get a host group of a target by name
+func (h *HostGroupOp) GetHostGroup(ctx context.Context, tgtId, name string) (*HostGroup, error) {
+	req, err := h.client.NewRequest(ctx, http.MethodGet, hostGroupPath(tgtId, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := HostGroup{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CreateHostGroup create a host group on a target
+func (h *HostGroupOp) CreateHostGroup(ctx context.Context, tgtId string, group *HostGroup) (*HostGroup, error) {
+	if group.Name == "" {
+		return nil, fmt.Errorf("host group name is required")
+	}
+	if err := validateHosts(group.Hosts); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(group)
+	req, err := h.client.NewRequest(ctx, http.MethodPost, "/rest/v2/dataTransfer/targets/"+tgtId+"/hostGroups", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := HostGroup{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UpdateHostGroup update a host group of a target, the group is renamed to group.Name and its hosts are replaced by group.Hosts
+func (h *HostGroupOp) UpdateHostGroup(ctx context.Context, tgtId, name string, group *HostGroup) (*HostGroup, error) {
+	if name == "" || group.Name == "" {
+		return nil, fmt.Errorf("host group name is required")
+	}
+	if err := validateHosts(group.Hosts); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(group)
+	req, err := h.client.NewRequest(ctx, http.MethodPut, hostGroupPath(tgtId, name), string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := HostGroup{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// DeleteHostGroup delete a host group from a target
+func (h *HostGroupOp) DeleteHostGroup(ctx context.Context, tgtId, name string) error {
+	req, err := h.client.NewRequest(ctx, http.MethodDelete, hostGroupPath(tgtId, name), nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddInitiators add a host with the initiators to a host group of a target, ex a new Kubernetes node
+func (h *HostGroupOp) AddInitiators(ctx context.Context, tgtId, name string, initiators ...string) (*HostGroup, error) {
+	host := NewHost(initiators...)
+	if err := validateHosts([]Host{host}); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(host)
+	req, err := h.client.NewRequest(ctx, http.MethodPost, hostGroupPath(tgtId, name)+"/hosts", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := HostGroup{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// RemoveInitiator remove an initiator from a host group of a target
+func (h *HostGroupOp) RemoveInitiator(ctx context.Context, tgtId, name, initiator string) error {
+	req, err := h.client.NewRequest(ctx, http.MethodDelete, hostGroupPath(tgtId, name)+"/hosts/"+url.PathEscape(initiator), nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := h.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
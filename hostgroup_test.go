@@ -0,0 +1,192 @@
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestValidateInitiator(t *testing.T) {
+	valids := []string{
+		"*",
+		"iqn.1993-08.org.debian:01:8f3a2b4c5d6e",
+		"iqn.2004-08.com.qsan:xs5216-000d40000:dev0.ctr1",
+		"eui.02004567A425678D",
+		"naa.52004567ba64678d",
+		"21000024ff4c8f4a",
+		"21:00:00:24:FF:4C:8F:4A",
+	}
+	for _, name := range valids {
+		if err := ValidateInitiator(name); err != nil {
+			t.Errorf("ValidateInitiator(%q) failed: %v", name, err)
+		}
+	}
+
+	invalids := []string{"", "**", "iqn.93-08.org.debian", "iqn.1993-08", "eui.1234", "21000024ff4c8f", "node1"}
+	for _, name := range invalids {
+		if err := ValidateInitiator(name); err == nil {
+			t.Errorf("ValidateInitiator(%q) expected an error", name)
+		}
+	}
+}
+
+func TestHostGroup(t *testing.T) {
+	fake := newFakeServer(t)
+	groups := map[string]*HostGroup{}
+	basePath := "/rest/v2/dataTransfer/targets/tgt1/hostGroups"
+	withGroup := func(name string, h func(w http.ResponseWriter, r *http.Request, group *HostGroup)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			group, ok := groups[name]
+			if !ok {
+				writeError(w, http.StatusNotFound, "host group not found")
+				return
+			}
+			h(w, r, group)
+		}
+	}
+	handleHosts := func(name string, hosts []Host) {
+		for _, host := range hosts {
+			for _, initiator := range host.Name {
+				initiator := initiator
+				fake.handle(http.MethodDelete, basePath+"/"+name+"/hosts/"+initiator, withGroup(name, func(w http.ResponseWriter, r *http.Request, group *HostGroup) {
+					hosts := []Host{}
+					for _, h := range group.Hosts {
+						names := []string{}
+						for _, n := range h.Name {
+							if n != initiator {
+								names = append(names, n)
+							}
+						}
+						if len(names) > 0 {
+							hosts = append(hosts, Host{Name: names})
+						}
+					}
+					group.Hosts = hosts
+					writeJSON(w, http.StatusOK, EmptyData{})
+				}))
+			}
+		}
+	}
+	var handleGroup func(name string)
+	handleGroup = func(name string) {
+		fake.handle(http.MethodGet, basePath+"/"+name, withGroup(name, func(w http.ResponseWriter, r *http.Request, group *HostGroup) {
+			writeJSON(w, http.StatusOK, group)
+		}))
+		fake.handle(http.MethodDelete, basePath+"/"+name, withGroup(name, func(w http.ResponseWriter, r *http.Request, group *HostGroup) {
+			delete(groups, name)
+			writeJSON(w, http.StatusOK, EmptyData{})
+		}))
+		fake.handle(http.MethodPost, basePath+"/"+name+"/hosts", withGroup(name, func(w http.ResponseWriter, r *http.Request, group *HostGroup) {
+			host := Host{}
+			json.NewDecoder(r.Body).Decode(&host)
+			group.Hosts = append(group.Hosts, host)
+			handleHosts(name, []Host{host})
+			writeJSON(w, http.StatusOK, group)
+		}))
+		fake.handle(http.MethodPut, basePath+"/"+name, withGroup(name, func(w http.ResponseWriter, r *http.Request, group *HostGroup) {
+			update := HostGroup{}
+			json.NewDecoder(r.Body).Decode(&update)
+			delete(groups, name)
+			groups[update.Name] = &update
+			handleGroup(update.Name)
+			handleHosts(update.Name, update.Hosts)
+			writeJSON(w, http.StatusOK, update)
+		}))
+	}
+	fake.handle(http.MethodPost, basePath, func(w http.ResponseWriter, r *http.Request) {
+		group := HostGroup{}
+		json.NewDecoder(r.Body).Decode(&group)
+		groups[group.Name] = &group
+		handleGroup(group.Name)
+		handleHosts(group.Name, group.Hosts)
+		writeJSON(w, http.StatusOK, group)
+	})
+	fake.handle(http.MethodGet, basePath, func(w http.ResponseWriter, r *http.Request) {
+		res := []HostGroup{}
+		for _, group := range groups {
+			res = append(res, *group)
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	hostGroupOp := NewHostGroup(fake.authClient(t))
+	ctx := context.Background()
+	node1 := "iqn.1993-08.org.debian:01:node1"
+	node2 := "iqn.1993-08.org.debian:01:node2"
+
+	_, err := hostGroupOp.CreateHostGroup(ctx, "tgt1", &HostGroup{Name: "k8s", Hosts: []Host{NewHost(node1)}})
+	if err != nil {
+		t.Fatalf("CreateHostGroup failed: %v", err)
+	}
+	if _, err := hostGroupOp.CreateHostGroup(ctx, "tgt1", &HostGroup{Name: "bad", Hosts: []Host{NewHost("node1")}}); err == nil {
+		t.Fatalf("CreateHostGroup with an invalid initiator expected an error")
+	}
+
+	group, err := hostGroupOp.AddInitiators(ctx, "tgt1", "k8s", node2)
+	if err != nil {
+		t.Fatalf("AddInitiators failed: %v", err)
+	}
+	if !reflect.DeepEqual(group.Hosts, []Host{NewHost(node1), NewHost(node2)}) {
+		t.Fatalf("unexpected hosts: %+v", group.Hosts)
+	}
+	if _, err := hostGroupOp.AddInitiators(ctx, "tgt1", "k8s"); err == nil {
+		t.Fatalf("AddInitiators without initiator expected an error")
+	}
+
+	if err := hostGroupOp.RemoveInitiator(ctx, "tgt1", "k8s", node1); err != nil {
+		t.Fatalf("RemoveInitiator failed: %v", err)
+	}
+	group, err = hostGroupOp.GetHostGroup(ctx, "tgt1", "k8s")
+	if err != nil {
+		t.Fatalf("GetHostGroup failed: %v", err)
+	}
+	if !reflect.DeepEqual(group.Hosts, []Host{NewHost(node2)}) {
+		t.Fatalf("unexpected hosts: %+v", group.Hosts)
+	}
+
+	// rename the group and replace its hosts
+	group, err = hostGroupOp.UpdateHostGroup(ctx, "tgt1", "k8s", &HostGroup{Name: "k8s-prod", Hosts: []Host{NewHost(node1)}})
+	if err != nil {
+		t.Fatalf("UpdateHostGroup failed: %v", err)
+	}
+	if group.Name != "k8s-prod" || !reflect.DeepEqual(group.Hosts, []Host{NewHost(node1)}) {
+		t.Fatalf("unexpected host group: %+v", *group)
+	}
+	if _, err := hostGroupOp.GetHostGroup(ctx, "tgt1", "k8s"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetHostGroup of a renamed group expected ErrNotFound, got %v", err)
+	}
+	if group, err = hostGroupOp.GetHostGroup(ctx, "tgt1", "k8s-prod"); err != nil || !reflect.DeepEqual(group.Hosts, []Host{NewHost(node1)}) {
+		t.Fatalf("GetHostGroup of the renamed group failed: %v, %+v", err, group)
+	}
+
+	invalids := []*HostGroup{
+		{Hosts: []Host{NewHost(node1)}},
+		{Name: "k8s", Hosts: []Host{NewHost("node1")}},
+		{Name: "k8s", Hosts: []Host{{}}},
+	}
+	expectInvalid(t, invalids, func(i int) error {
+		_, err := hostGroupOp.UpdateHostGroup(ctx, "tgt1", "k8s-prod", invalids[i])
+		return err
+	})
+	if n := fake.count(http.MethodPut, basePath+"/k8s-prod"); n != 0 {
+		t.Fatalf("expected no update request of invalid groups, got %d", n)
+	}
+
+	groupList, err := hostGroupOp.ListHostGroups(ctx, "tgt1")
+	if err != nil {
+		t.Fatalf("ListHostGroups failed: %v", err)
+	}
+	if len(*groupList) != 1 {
+		t.Fatalf("unexpected host groups: %+v", *groupList)
+	}
+
+	if err := hostGroupOp.DeleteHostGroup(ctx, "tgt1", "k8s-prod"); err != nil {
+		t.Fatalf("DeleteHostGroup failed: %v", err)
+	}
+	if _, err := hostGroupOp.GetHostGroup(ctx, "tgt1", "k8s-prod"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetHostGroup of a deleted group expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// NetworkOp handles network port related methods of the QSM storage.
+type NetworkOp struct {
+	client *AuthClient
+}
+
+// LinkState is the link state of a network port.
+type LinkState string
+
+const (
+	LinkUp   LinkState = "up"
+	LinkDown LinkState = "down"
+)
+
+// The response data of ListPorts method
+type PortData struct {
+	Name       string    `json:"name"`       // port name used by target Eths, ex "c0e1"
+	Controller int       `json:"controller"` // controller index, ex 0 or 1
+	MAC        string    `json:"mac"`
+	IP         string    `json:"ip"`
+	Netmask    string    `json:"netmask"`
+	Gateway    string    `json:"gateway"`
+	MTU        int       `json:"mtu"`
+	SpeedMbps  int       `json:"speedMbps"` // negotiated link speed
+	LinkState  LinkState `json:"linkState"`
+}
+
+// Healthy reports whether the port has link and an IP address, so it can serve a target.
+func (p *PortData) Healthy() bool {
+	return p.LinkState == LinkUp && p.IP != ""
+}
+
+// NewNetwork returns network operation
+func NewNetwork(client *AuthClient) *NetworkOp {
+	return &NetworkOp{client}
+}
+
+// ListPorts list the data ports of all controllers
+func (n *NetworkOp) ListPorts(ctx context.Context) (*[]PortData, error) {
+	req, err := n.client.NewRequest(ctx, http.MethodGet, "/rest/v2/system/network/dataPorts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []PortData{}
+	if err := n.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// PickTargetEths picks count healthy ports for the Eths of a new target. The ports are taken
+// from the controllers in turn, so a target survives a controller failure, and faster ports come first.
+func PickTargetEths(ports []PortData, count int) ([]string, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("invalid port count %d", count)
+	}
+
+	healthy := map[int][]PortData{}
+	ctrls := []int{}
+	for _, p := range ports {
+		if !p.Healthy() {
+			continue
+		}
+		if _, ok := healthy[p.Controller]; !ok {
+			ctrls = append(ctrls, p.Controller)
+		}
+		healthy[p.Controller] = append(healthy[p.Controller], p)
+	}
+	sort.Ints(ctrls)
+	for _, c := range ctrls {
+		ps := healthy[c]
+		sort.SliceStable(ps, func(i, j int) bool {
+			if ps[i].SpeedMbps != ps[j].SpeedMbps {
+				return ps[i].SpeedMbps > ps[j].SpeedMbps
+			}
+			return ps[i].Name < ps[j].Name
+		})
+	}
+
+	eths := []string{}
+	for i := 0; len(eths) < count; i++ {
+		picked := false
+		for _, c := range ctrls {
+			if i < len(healthy[c]) && len(eths) < count {
+				eths = append(eths, healthy[c][i].Name)
+				picked = true
+			}
+		}
+		if !picked {
+			return nil, fmt.Errorf("only %d healthy ports, %d required", len(eths), count)
+		}
+	}
+
+	return eths, nil
+}
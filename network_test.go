@@ -0,0 +1,65 @@
+package goqsm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestListPorts(t *testing.T) {
+	fake := newFakeServer(t)
+	ports := []PortData{
+		{Name: "c0e1", Controller: 0, IP: "192.168.10.1", Netmask: "255.255.255.0", MTU: 9000, SpeedMbps: 10000, LinkState: LinkUp},
+		{Name: "c1e1", Controller: 1, IP: "192.168.10.2", Netmask: "255.255.255.0", MTU: 9000, SpeedMbps: 10000, LinkState: LinkDown},
+	}
+	fake.handleJSON(http.MethodGet, "/rest/v2/system/network/dataPorts", ports)
+	networkOp := NewNetwork(fake.authClient(t))
+
+	res, err := networkOp.ListPorts(context.Background())
+	if err != nil {
+		t.Fatalf("ListPorts failed: %v", err)
+	}
+	if !reflect.DeepEqual(*res, ports) {
+		t.Fatalf("unexpected ports: %+v", *res)
+	}
+	if !(*res)[0].Healthy() || (*res)[1].Healthy() {
+		t.Fatalf("unexpected port health: %+v", *res)
+	}
+}
+
+func TestPickTargetEths(t *testing.T) {
+	ports := []PortData{
+		{Name: "c0e1", Controller: 0, IP: "10.0.0.1", SpeedMbps: 1000, LinkState: LinkUp},
+		{Name: "c0e2", Controller: 0, IP: "10.0.0.2", SpeedMbps: 10000, LinkState: LinkUp},
+		{Name: "c0e3", Controller: 0, IP: "10.0.0.3", SpeedMbps: 10000, LinkState: LinkDown},
+		{Name: "c1e1", Controller: 1, IP: "10.0.1.1", SpeedMbps: 1000, LinkState: LinkUp},
+		{Name: "c1e2", Controller: 1, SpeedMbps: 10000, LinkState: LinkUp},
+	}
+
+	tests := []struct {
+		count int
+		want  []string
+	}{
+		{1, []string{"c0e2"}},
+		{2, []string{"c0e2", "c1e1"}},
+		{3, []string{"c0e2", "c1e1", "c0e1"}},
+	}
+	for _, tt := range tests {
+		eths, err := PickTargetEths(ports, tt.count)
+		if err != nil {
+			t.Errorf("PickTargetEths(%d) failed: %v", tt.count, err)
+			continue
+		}
+		if !reflect.DeepEqual(eths, tt.want) {
+			t.Errorf("PickTargetEths(%d) = %v, want %v", tt.count, eths, tt.want)
+		}
+	}
+
+	if _, err := PickTargetEths(ports, 4); err == nil {
+		t.Errorf("PickTargetEths(4) expected an error")
+	}
+	if _, err := PickTargetEths(ports, 0); err == nil {
+		t.Errorf("PickTargetEths(0) expected an error")
+	}
+}
@@ -0,0 +1,266 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PoolOp handles RAID pool related methods of the QSM storage.
+type PoolOp struct {
+	client *AuthClient
+}
+
+// RaidLevel is the RAID level of a pool.
+type RaidLevel string
+
+const (
+	Raid0  RaidLevel = "RAID0"
+	Raid1  RaidLevel = "RAID1"
+	Raid5  RaidLevel = "RAID5"
+	Raid6  RaidLevel = "RAID6"
+	Raid10 RaidLevel = "RAID10"
+	Raid50 RaidLevel = "RAID50"
+	Raid60 RaidLevel = "RAID60"
+)
+
+// minDisks returns the minimum number of member disks of the RAID level, 0 for an unknown level.
+func (r RaidLevel) minDisks() int {
+	switch r {
+	case Raid0:
+		return 1
+	case Raid1:
+		return 2
+	case Raid5:
+		return 3
+	case Raid6, Raid10:
+		return 4
+	case Raid50:
+		return 6
+	case Raid60:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// PoolState is the state of a pool.
+type PoolState string
+
+const (
+	PoolOnline     PoolState = "online"
+	PoolDegraded   PoolState = "degraded"   // a member disk failed, no rebuild is running
+	PoolRebuilding PoolState = "rebuilding" // a spare or replaced disk is rebuilding
+	PoolExpanding  PoolState = "expanding"  // new member disks are being added
+	PoolOffline    PoolState = "offline"
+)
+
+// The response data of pool related methods, ex ListPools and CreatePool.
+type PoolData struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	RaidLevel       RaidLevel `json:"raidLevel"`
+	State           PoolState `json:"state"`
+	Disks           []string  `json:"disks"`           // member disk IDs
+	Spares          []string  `json:"spares"`          // hot spare disk IDs
+	RebuildProgress int       `json:"rebuildProgress"` // percent of a rebuild in PoolRebuilding state
+	SizeMB          uint64    `json:"sizeMB"`
+	UsedMB          uint64    `json:"usedMB"`
+	FreeMB          uint64    `json:"freeMB"`
+}
+
+type poolDisksParam struct {
+	Name      string    `json:"name,omitempty"`
+	RaidLevel RaidLevel `json:"raidLevel,omitempty"`
+	Disks     []string  `json:"disks"`
+}
+
+// Size returns the size of the pool.
+func (d *PoolData) Size() Capacity {
+	return CapacityFromMB(d.SizeMB)
+}
+
+// Free returns the free space of the pool.
+func (d *PoolData) Free() Capacity {
+	return CapacityFromMB(d.FreeMB)
+}
+
+func validateDiskIds(diskIds []string) error {
+	if len(diskIds) == 0 {
+		return fmt.Errorf("no disk is given")
+	}
+
+	seen := map[string]bool{}
+	for _, id := range diskIds {
+		if id == "" {
+			return fmt.Errorf("empty disk ID")
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicate disk %s", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// NewPool returns pool operation
+func NewPool(client *AuthClient) *PoolOp {
+	return &PoolOp{client}
+}
+
+// ListPools list all pools
+func (p *PoolOp) ListPools(ctx context.Context) (*[]PoolData, error) {
+	req, err := p.client.NewRequest(ctx, http.MethodGet, "/rest/v2/storage/pools", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []PoolData{}
+	if err := p.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetPool get a pool with poolId
+func (p *PoolOp) GetPool(ctx context.Context, poolId string) (*PoolData, error) {
+	req, err := p.client.NewRequest(ctx, http.MethodGet, "/rest/v2/storage/pools/"+poolId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := PoolData{}
+	if err := p.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CreatePool create a pool with the RAID level from free disks
+func (p *PoolOp) CreatePool(ctx context.Context, name string, raidLevel RaidLevel,
+	diskIds []string) (*PoolData, error) {
+	if name == "" {
+		return nil, fmt.Errorf("pool name is required")
+	}
+	minDisks := raidLevel.minDisks()
+	if minDisks == 0 {
+		return nil, fmt.Errorf("invalid RAID level %q", raidLevel)
+	}
+	if err := validateDiskIds(diskIds); err != nil {
+		return nil, err
+	}
+	if len(diskIds) < minDisks {
+		return nil, fmt.Errorf("%s requires at least %d disks, %d given",
+			raidLevel, minDisks, len(diskIds))
+	}
+	if raidLevel == Raid10 && len(diskIds)%2 != 0 {
+		return nil, fmt.Errorf("%s requires an even number of disks, %d given",
+			raidLevel, len(diskIds))
+	}
+
+	rawdata, _ := json.Marshal(poolDisksParam{Name: name, RaidLevel: raidLevel, Disks: diskIds})
+	req, err := p.client.NewRequest(ctx, http.MethodPost, "/rest/v2/storage/pools", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := PoolData{}
+	if err := p.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ExpandPool add free disks to a pool, the pool is in PoolExpanding state until the new disks
+// are in use
+func (p *PoolOp) ExpandPool(ctx context.Context, poolId string,
+	diskIds []string) (*PoolData, error) {
+	if err := validateDiskIds(diskIds); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(poolDisksParam{Disks: diskIds})
+	path := "/rest/v2/storage/pools/" + poolId + "/expand"
+	req, err := p.client.NewRequest(ctx, http.MethodPost, path, string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := PoolData{}
+	if err := p.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// AddSpares add free disks to a pool as hot spares
+func (p *PoolOp) AddSpares(ctx context.Context, poolId string,
+	diskIds []string) (*PoolData, error) {
+	if err := validateDiskIds(diskIds); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(poolDisksParam{Disks: diskIds})
+	path := "/rest/v2/storage/pools/" + poolId + "/spares"
+	req, err := p.client.NewRequest(ctx, http.MethodPost, path, string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := PoolData{}
+	if err := p.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// The polling interval of WaitPoolState when none is given
+const defaultPoolPollInterval = 5 * time.Second
+
+// newPoolTicker starts the ticker of WaitPoolState, tests replace it to poll faster.
+var newPoolTicker = time.NewTicker
+
+// WaitPoolState polls a pool every interval until it reaches the state, ex PoolOnline after
+// ExpandPool or a rebuild. A non-positive interval polls every 5 seconds. The optional progress
+// callback receives every polled pool. An error is returned if the pool goes offline or ctx is
+// done.
+func (p *PoolOp) WaitPoolState(ctx context.Context, poolId string, state PoolState,
+	interval time.Duration, progress func(*PoolData)) (*PoolData, error) {
+	if interval <= 0 {
+		interval = defaultPoolPollInterval
+	}
+	ticker := newPoolTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pool, err := p.GetPool(ctx, poolId)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			progress(pool)
+		}
+		if pool.State == state {
+			return pool, nil
+		}
+		if pool.State == PoolOffline {
+			return pool, fmt.Errorf("pool %s is offline", poolId)
+		}
+
+		select {
+		case <-ctx.Done():
+			return pool, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fakeDiskMB = 1024 * 1024
+
+// fakePools serves the pool APIs on a fake server. A pool moves one step toward its target state
+// on every GET, so the state transitions of expansion and rebuild can be observed by polling.
+type fakePools struct {
+	mu    sync.Mutex
+	fake  *fakeServer
+	pools map[string]*PoolData
+	free  map[string]bool // free disk IDs
+}
+
+func newFakePools(fake *fakeServer, freeDisks ...string) *fakePools {
+	fp := &fakePools{fake: fake, pools: map[string]*PoolData{}, free: map[string]bool{}}
+	for _, id := range freeDisks {
+		fp.free[id] = true
+	}
+
+	fake.handle(http.MethodPost, "/rest/v2/storage/pools", func(w http.ResponseWriter, r *http.Request) {
+		param := poolDisksParam{}
+		json.NewDecoder(r.Body).Decode(&param)
+
+		fp.mu.Lock()
+		defer fp.mu.Unlock()
+		if !fp.takeDisks(param.Disks) {
+			writeError(w, http.StatusBadRequest, "disk is not free")
+			return
+		}
+		pool := &PoolData{ID: "pool-" + param.Name, Name: param.Name, RaidLevel: param.RaidLevel, State: PoolOnline, Disks: param.Disks, Spares: []string{}}
+		pool.SizeMB = fp.dataDisks(pool) * fakeDiskMB
+		pool.FreeMB = pool.SizeMB
+		fp.pools[pool.ID] = pool
+		fp.handlePool(pool.ID)
+		writeJSON(w, http.StatusOK, pool)
+	})
+	fake.handle(http.MethodGet, "/rest/v2/storage/pools", func(w http.ResponseWriter, r *http.Request) {
+		fp.mu.Lock()
+		defer fp.mu.Unlock()
+		res := []PoolData{}
+		for _, pool := range fp.pools {
+			res = append(res, *pool)
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	return fp
+}
+
+func (fp *fakePools) takeDisks(ids []string) bool {
+	for _, id := range ids {
+		if !fp.free[id] {
+			return false
+		}
+	}
+	for _, id := range ids {
+		delete(fp.free, id)
+	}
+	return true
+}
+
+func (fp *fakePools) dataDisks(pool *PoolData) uint64 {
+	n := uint64(len(pool.Disks))
+	switch pool.RaidLevel {
+	case Raid5:
+		return n - 1
+	case Raid6:
+		return n - 2
+	case Raid1, Raid10:
+		return n / 2
+	}
+	return n
+}
+
+// step moves a pool one step toward the online state.
+func (fp *fakePools) step(pool *PoolData) {
+	switch pool.State {
+	case PoolExpanding:
+		pool.State = PoolOnline
+		pool.SizeMB = fp.dataDisks(pool) * fakeDiskMB
+		pool.FreeMB = pool.SizeMB - pool.UsedMB
+	case PoolDegraded:
+		if len(pool.Spares) > 0 {
+			pool.Disks = append(pool.Disks, pool.Spares[0])
+			pool.Spares = pool.Spares[1:]
+			pool.State = PoolRebuilding
+			pool.RebuildProgress = 0
+		}
+	case PoolRebuilding:
+		pool.RebuildProgress += 50
+		if pool.RebuildProgress >= 100 {
+			pool.State = PoolOnline
+			pool.RebuildProgress = 0
+		}
+	}
+}
+
+// failDisk simulates a failure of a member disk.
+func (fp *fakePools) failDisk(poolId, diskId string) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	pool := fp.pools[poolId]
+	for i, id := range pool.Disks {
+		if id == diskId {
+			pool.Disks = append(pool.Disks[:i], pool.Disks[i+1:]...)
+			pool.State = PoolDegraded
+			if len(pool.Disks) < pool.RaidLevel.minDisks()-2 {
+				pool.State = PoolOffline
+			}
+			return
+		}
+	}
+}
+
+func (fp *fakePools) handlePool(id string) {
+	path := "/rest/v2/storage/pools/" + id
+	withPool := func(h func(w http.ResponseWriter, r *http.Request, pool *PoolData)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fp.mu.Lock()
+			defer fp.mu.Unlock()
+			pool, ok := fp.pools[id]
+			if !ok {
+				writeError(w, http.StatusNotFound, "pool not found")
+				return
+			}
+			h(w, r, pool)
+		}
+	}
+
+	fp.fake.handle(http.MethodGet, path, withPool(func(w http.ResponseWriter, r *http.Request, pool *PoolData) {
+		res := *pool
+		fp.step(pool)
+		writeJSON(w, http.StatusOK, res)
+	}))
+	fp.fake.handle(http.MethodPost, path+"/expand", withPool(func(w http.ResponseWriter, r *http.Request, pool *PoolData) {
+		param := poolDisksParam{}
+		json.NewDecoder(r.Body).Decode(&param)
+		if pool.State != PoolOnline {
+			writeError(w, http.StatusConflict, "pool is busy")
+			return
+		}
+		if !fp.takeDisks(param.Disks) {
+			writeError(w, http.StatusBadRequest, "disk is not free")
+			return
+		}
+		pool.Disks = append(pool.Disks, param.Disks...)
+		pool.State = PoolExpanding
+		writeJSON(w, http.StatusOK, pool)
+	}))
+	fp.fake.handle(http.MethodPost, path+"/spares", withPool(func(w http.ResponseWriter, r *http.Request, pool *PoolData) {
+		param := poolDisksParam{}
+		json.NewDecoder(r.Body).Decode(&param)
+		if !fp.takeDisks(param.Disks) {
+			writeError(w, http.StatusBadRequest, "disk is not free")
+			return
+		}
+		pool.Spares = append(pool.Spares, param.Disks...)
+		writeJSON(w, http.StatusOK, pool)
+	}))
+}
+
+func TestCreatePool(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakePools(fake, "d0", "d1", "d2", "d3", "d4", "d5")
+	poolOp := NewPool(fake.authClient(t))
+	ctx := context.Background()
+
+	pool, err := poolOp.CreatePool(ctx, "p1", Raid5, []string{"d0", "d1", "d2"})
+	if err != nil {
+		t.Fatalf("CreatePool failed: %v", err)
+	}
+	if pool.State != PoolOnline || pool.Size() != 2*TiB || pool.RaidLevel != Raid5 {
+		t.Fatalf("unexpected pool: %+v", *pool)
+	}
+	if _, err := poolOp.CreatePool(ctx, "p2", Raid1, []string{"d2", "d3"}); err == nil {
+		t.Fatalf("CreatePool with a used disk expected an error")
+	}
+
+	invalids := []struct {
+		name  string
+		raid  RaidLevel
+		disks []string
+	}{
+		{"", Raid5, []string{"d3", "d4", "d5"}},
+		{"p3", "RAID7", []string{"d3", "d4", "d5"}},
+		{"p3", Raid6, []string{"d3", "d4", "d5"}},
+		{"p3", Raid10, []string{"d3", "d4", "d5", "d6", "d7"}},
+		{"p3", Raid5, []string{"d3", "d4", "d4"}},
+		{"p3", Raid0, nil},
+	}
+	for _, tt := range invalids {
+		if _, err := poolOp.CreatePool(ctx, tt.name, tt.raid, tt.disks); err == nil {
+			t.Errorf("CreatePool(%s, %s, %v) expected an error", tt.name, tt.raid, tt.disks)
+		}
+	}
+	if n := fake.count(http.MethodPost, "/rest/v2/storage/pools"); n != 2 {
+		t.Fatalf("expected 2 create requests, got %d", n)
+	}
+
+	pools, err := poolOp.ListPools(ctx)
+	if err != nil {
+		t.Fatalf("ListPools failed: %v", err)
+	}
+	if len(*pools) != 1 || (*pools)[0].ID != pool.ID {
+		t.Fatalf("unexpected pools: %+v", *pools)
+	}
+
+	if _, err := poolOp.GetPool(ctx, "pool-p9"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetPool of an unknown pool expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPoolStateTransitions(t *testing.T) {
+	fake := newFakeServer(t)
+	fp := newFakePools(fake, "d0", "d1", "d2", "d3", "d4", "d5", "d6")
+	poolOp := NewPool(fake.authClient(t))
+	ctx := context.Background()
+	var states []PoolState
+	var progresses []int
+	progress := func(pool *PoolData) {
+		states = append(states, pool.State)
+		if pool.State == PoolRebuilding {
+			progresses = append(progresses, pool.RebuildProgress)
+		}
+	}
+
+	pool, err := poolOp.CreatePool(ctx, "p1", Raid6, []string{"d0", "d1", "d2", "d3"})
+	if err != nil {
+		t.Fatalf("CreatePool failed: %v", err)
+	}
+
+	// online -> expanding -> online
+	pool, err = poolOp.ExpandPool(ctx, pool.ID, []string{"d4"})
+	if err != nil {
+		t.Fatalf("ExpandPool failed: %v", err)
+	}
+	if pool.State != PoolExpanding {
+		t.Fatalf("expected expanding pool, got %s", pool.State)
+	}
+	if _, err := poolOp.ExpandPool(ctx, pool.ID, []string{"d5"}); err == nil {
+		t.Fatalf("ExpandPool of an expanding pool expected an error")
+	}
+	pool, err = poolOp.WaitPoolState(ctx, pool.ID, PoolOnline, time.Millisecond, progress)
+	if err != nil {
+		t.Fatalf("WaitPoolState failed: %v", err)
+	}
+	if !reflect.DeepEqual(states, []PoolState{PoolExpanding, PoolOnline}) || pool.Size() != 3*TiB {
+		t.Fatalf("unexpected expansion: %v, %+v", states, *pool)
+	}
+
+	pool, err = poolOp.AddSpares(ctx, pool.ID, []string{"d5"})
+	if err != nil {
+		t.Fatalf("AddSpares failed: %v", err)
+	}
+	if !reflect.DeepEqual(pool.Spares, []string{"d5"}) {
+		t.Fatalf("unexpected spares: %v", pool.Spares)
+	}
+	if _, err := poolOp.AddSpares(ctx, pool.ID, nil); err == nil {
+		t.Fatalf("AddSpares without disk expected an error")
+	}
+	// a zero interval uses the default, the online pool returns at once
+	if pool, err = poolOp.WaitPoolState(ctx, pool.ID, PoolOnline, 0, nil); err != nil {
+		t.Fatalf("WaitPoolState with zero interval failed: %v", err)
+	}
+
+	// online -> degraded -> rebuilding 0%, 50% -> online, polled with the default interval
+	var interval time.Duration
+	newPoolTicker = func(d time.Duration) *time.Ticker {
+		interval = d
+		return time.NewTicker(time.Millisecond)
+	}
+	defer func() { newPoolTicker = time.NewTicker }()
+	states = nil
+	fp.failDisk(pool.ID, "d1")
+	pool, err = poolOp.WaitPoolState(ctx, pool.ID, PoolOnline, 0, progress)
+	if err != nil {
+		t.Fatalf("WaitPoolState failed: %v", err)
+	}
+	if interval != defaultPoolPollInterval {
+		t.Fatalf("expected the default interval, got %v", interval)
+	}
+	if !reflect.DeepEqual(states, []PoolState{PoolDegraded, PoolRebuilding, PoolRebuilding, PoolOnline}) || !reflect.DeepEqual(progresses, []int{0, 50}) {
+		t.Fatalf("unexpected rebuild: %v %v", states, progresses)
+	}
+	if len(pool.Disks) != 5 || len(pool.Spares) != 0 {
+		t.Fatalf("spare did not replace the failed disk: %+v", *pool)
+	}
+
+	// degraded without spare stays degraded until ctx is done
+	fp.failDisk(pool.ID, "d2")
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err = poolOp.WaitPoolState(waitCtx, pool.ID, PoolOnline, time.Millisecond, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	waitCtx, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err = poolOp.WaitPoolState(waitCtx, pool.ID, PoolOnline, -time.Second, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded with a negative interval, got %v", err)
+	}
+	if pool, err = poolOp.GetPool(ctx, pool.ID); err != nil || pool.State != PoolDegraded {
+		t.Fatalf("expected a degraded pool, got %v", err)
+	}
+
+	// too many failed disks take the pool offline
+	fp.failDisk(pool.ID, "d3")
+	fp.failDisk(pool.ID, "d4")
+	fp.failDisk(pool.ID, "d0")
+	if _, err := poolOp.WaitPoolState(ctx, pool.ID, PoolOnline, time.Millisecond, nil); err == nil {
+		t.Fatalf("WaitPoolState of an offline pool expected an error")
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -17,15 +20,24 @@ import (
 
 // QSM client without authentication
 type Client struct {
-	apiKey     string
-	baseURL    string
-	HTTPClient *http.Client
+	mu           sync.RWMutex // guards apiKey
+	apiKey       string
+	baseURL      string
+	nameCacheTTL time.Duration
+	HTTPClient   *http.Client
 }
 
+// DefaultNameCacheTTL is the default time to live of the storage container name cache.
+const DefaultNameCacheTTL = 5 * time.Minute
+
 // ClientOptions are options for QSM http client.
 type ClientOptions struct {
 	Https      bool
 	ReqTimeout time.Duration
+
+	// NameCacheTTL is the time to live of the storage container name cache of ResolveContainerID,
+	// 0 uses DefaultNameCacheTTL and a negative value disables the cache.
+	NameCacheTTL time.Duration
 }
 
 // QSM client with authentication
@@ -33,6 +45,23 @@ type AuthClient struct {
 	Client
 	accessToken  string
 	refreshToken string
+	tokenSource  TokenSource // set by GetAuthClientWithToken
+
+	containerNames nameCache
+}
+
+// TokenSource supplies a pre-issued API token, ex read from a secret that is rotated.
+// Token is called again when the storage rejects the current token.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource of a fixed API token.
+type StaticToken string
+
+// Token returns the fixed API token.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
 }
 
 // For authentication
@@ -45,6 +74,43 @@ type AuthRes struct {
 // Empty response data
 type EmptyData []interface{}
 
+// ErrNotFound is matched by errors.Is when the storage reports that a resource does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrTokenExpired is matched by errors.Is when the storage rejects the token of a client,
+// ex an expired API token that its TokenSource did not replace.
+var ErrTokenExpired = errors.New("token expired")
+
+// ErrPermissionDenied is matched by errors.Is when the role of the user is not allowed to do a request.
+var ErrPermissionDenied = errors.New("permission denied")
+
+// APIError is returned when the storage responds with an error status.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Code       int    // error code of the storage
+	Message    string // error message of the storage
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("unknown error, status code: %d", e.StatusCode)
+	}
+	return e.Message
+}
+
+// Is reports whether the error matches one of the sentinel errors, ex errors.Is(err, ErrNotFound).
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrPermissionDenied:
+		return e.StatusCode == http.StatusForbidden
+	case ErrTokenExpired:
+		return e.StatusCode == http.StatusUnauthorized
+	}
+	return false
+}
+
 type errorResponse struct {
 	Error struct {
 		Message string `json:"message"`
@@ -55,14 +121,19 @@ type errorResponse struct {
 // NewClient returns QSM client with given URL
 func NewClient(ip string, opts ClientOptions) *Client {
 	client := &Client{
-		HTTPClient: &http.Client{},
-		baseURL:    "http://" + ip,
+		HTTPClient:   &http.Client{},
+		baseURL:      "http://" + ip,
+		nameCacheTTL: DefaultNameCacheTTL,
 	}
 
 	if opts.ReqTimeout != 0 {
 		client.HTTPClient.Timeout = opts.ReqTimeout
 	}
 
+	if opts.NameCacheTTL != 0 {
+		client.nameCacheTTL = opts.NameCacheTTL
+	}
+
 	if opts.Https {
 	}
 
@@ -71,6 +142,7 @@ func NewClient(ip string, opts ClientOptions) *Client {
 
 // If body format is url.Values, then body data will be sent using x-www-form-urlencoded format.
 // If body format is string, then body data will be sent using raw data with JSON format.
+// If body format is io.Reader, then body data will be sent as is with octet-stream format, ex a firmware image.
 func (c *Client) NewRequest(ctx context.Context, method, urlPath string, body interface{}) (*http.Request, error) {
 	var (
 		req *http.Request
@@ -85,7 +157,7 @@ func (c *Client) NewRequest(ctx context.Context, method, urlPath string, body in
 	}
 
 	if body != nil {
-		glog.V(3).Infof("[NewRequest] body: %v\n", body)
+		glog.V(3).Infof("[NewRequest] body: %s\n", redactBody(body))
 		switch body := body.(type) {
 		case url.Values:
 			req, err = http.NewRequest(method, u.String(), strings.NewReader(body.Encode()))
@@ -94,8 +166,13 @@ func (c *Client) NewRequest(ctx context.Context, method, urlPath string, body in
 			// raw data
 			req, err = http.NewRequest(method, u.String(), strings.NewReader(body))
 			req.Header.Set("Content-Type", "application/json")
+		case io.Reader:
+			req, err = http.NewRequest(method, u.String(), body)
+			if err == nil {
+				req.Header.Set("Content-Type", "application/octet-stream")
+			}
 		default:
-			return nil, fmt.Errorf("Unknow body format! Only url.Values and string formats are supported.\n")
+			return nil, fmt.Errorf("Unknow body format! Only url.Values, string and io.Reader formats are supported.\n")
 		}
 	} else {
 		req, err = http.NewRequest(method, u.String(), nil)
@@ -109,38 +186,51 @@ func (c *Client) NewRequest(ctx context.Context, method, urlPath string, body in
 	return req, nil
 }
 
-func (c *AuthClient) SendRequest(ctx context.Context, req *http.Request, v interface{}) error {
-	res, err := c.doSendRequest(ctx, req, v)
-	if err != nil {
-		return err
+// The replacement of passwords, secrets and tokens in the logs
+const redacted = "******"
+
+var secretJSONRegexp = regexp.MustCompile(`("[^"]*(?i:secret|password|token)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// redactBody returns the request body for logging with passwords, secrets and tokens masked.
+func redactBody(body interface{}) string {
+	switch body := body.(type) {
+	case url.Values:
+		values := url.Values{}
+		for k, v := range body {
+			lk := strings.ToLower(k)
+			if strings.Contains(lk, "secret") || strings.Contains(lk, "password") || strings.Contains(lk, "token") {
+				v = []string{redacted}
+			}
+			values[k] = v
+		}
+		return values.Encode()
+	case string:
+		return secretJSONRegexp.ReplaceAllString(body, `$1"`+redacted+`"`)
+	case io.Reader:
+		return "<binary data>"
+	default:
+		return fmt.Sprintf("%v", body)
 	}
+}
 
-	if res.StatusCode == 401 {
-		res.Body.Close()
-
-		// When the existing access token expired, generate a new access token.
-		glog.V(2).Infof("[AuthSendRequest] generate new access token. (%s%s)\n", req.Host, req.URL.Path)
-		authRes, err := c.genAccessToken(ctx, c.refreshToken)
-		if err != nil {
-			return fmt.Errorf("genAccessToken failed: %v\n", err)
-		}
+// redactToken returns a token for logging, masked like the secrets of redactBody.
+func redactToken(t string) string {
+	if t == "" {
+		return ""
+	}
+	return redacted
+}
 
-		// Update new access token then send request again
-		c.accessToken = authRes.AccessToken
-		c.apiKey = authRes.AccessToken
-		glog.V(2).Infof("[AuthSendRequest] SendRequest again (%s%s)\n", req.Host, req.URL.Path)
-		res, err = c.doSendRequest(ctx, req, v)
+func (c *AuthClient) SendRequest(ctx context.Context, req *http.Request, v interface{}) error {
+	res, err := c.do(ctx, c.HTTPClient, req)
+	if err != nil {
+		return err
 	}
 
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		errRes := errorResponse{}
-		if err = json.NewDecoder(res.Body).Decode(&errRes); err == nil {
-			return errors.New(errRes.Error.Message)
-		}
-
-		return fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+		return newAPIError(res)
 	}
 
 	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
@@ -151,6 +241,69 @@ func (c *AuthClient) SendRequest(ctx context.Context, req *http.Request, v inter
 
 }
 
+// do sends the request with the http client and returns the response without checking the status code.
+// When the existing access token expired, the request is sent again with a new access token.
+func (c *AuthClient) do(ctx context.Context, hc *http.Client, req *http.Request) (*http.Response, error) {
+	res, err := c.send(ctx, hc, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 401 {
+		apiErr := newAPIError(res)
+		res.Body.Close()
+
+		if c.tokenSource != nil {
+			// Get the API token again, the token source may have a new one.
+			glog.V(2).Infof("[AuthSendRequest] get API token again. (%s%s)\n", req.Host, req.URL.Path)
+			token, err := c.tokenSource.Token(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("token source failed: %w", err)
+			}
+			// An empty token is no replacement, the request fails like with an unchanged one.
+			c.mu.RLock()
+			expired := token == "" || token == c.apiKey
+			c.mu.RUnlock()
+			if expired {
+				return nil, apiErr
+			}
+			c.setToken(token)
+		} else {
+			// When the existing access token expired, generate a new access token.
+			glog.V(2).Infof("[AuthSendRequest] generate new access token. (%s%s)\n", req.Host, req.URL.Path)
+			authRes, err := c.genAccessToken(ctx, c.refreshToken)
+			if err != nil {
+				return nil, fmt.Errorf("genAccessToken failed: %w", err)
+			}
+
+			// Update new access token then send request again
+			c.setToken(authRes.AccessToken)
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return nil, fmt.Errorf("access token expired, cannot send the request body again")
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		glog.V(2).Infof("[AuthSendRequest] SendRequest again (%s%s)\n", req.Host, req.URL.Path)
+		res, err = c.send(ctx, hc, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (c *AuthClient) setToken(t string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = t
+	c.apiKey = t
+}
+
 func (c *Client) SendRequest(ctx context.Context, req *http.Request, v interface{}) error {
 	res, err := c.doSendRequest(ctx, req, v)
 	if err != nil {
@@ -160,12 +313,7 @@ func (c *Client) SendRequest(ctx context.Context, req *http.Request, v interface
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		errRes := errorResponse{}
-		if err = json.NewDecoder(res.Body).Decode(&errRes); err == nil {
-			return errors.New(errRes.Error.Message)
-		}
-
-		return fmt.Errorf("unknown error, status code: %d", res.StatusCode)
+		return newAPIError(res)
 	}
 
 	if err = json.NewDecoder(res.Body).Decode(v); err != nil {
@@ -176,14 +324,32 @@ func (c *Client) SendRequest(ctx context.Context, req *http.Request, v interface
 
 }
 
+func newAPIError(res *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: res.StatusCode}
+	errRes := errorResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&errRes); err == nil {
+		apiErr.Code = errRes.Error.Code
+		apiErr.Message = errRes.Error.Message
+	}
+
+	return apiErr
+}
+
 func (c *Client) doSendRequest(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) {
-	if c.apiKey != "" {
-		glog.V(5).Infof("[doSendRequest] apiKey: %s\n", c.apiKey)
-		req.Header.Set("Authorization", c.apiKey)
+	return c.send(ctx, c.HTTPClient, req)
+}
+
+func (c *Client) send(ctx context.Context, hc *http.Client, req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	apiKey := c.apiKey
+	c.mu.RUnlock()
+	if apiKey != "" {
+		glog.V(5).Infof("[doSendRequest] apiKey: %s\n", redactToken(apiKey))
+		req.Header.Set("Authorization", apiKey)
 	}
 
 	req = req.WithContext(ctx)
-	res, err := c.HTTPClient.Do(req)
+	res, err := hc.Do(req)
 	if err != nil {
 		glog.Errorf("[doSendRequest] err: %v\n", err)
 		return nil, err
@@ -236,15 +402,43 @@ func (c *Client) GetAuthClient(ctx context.Context, user string, passwd string)
 		return nil, fmt.Errorf("login failed: %v\n", err)
 	}
 
-	glog.V(3).Infof("AccessToken: %s\n", res.AccessToken)
+	glog.V(3).Infof("AccessToken: %s\n", redactToken(res.AccessToken))
 
 	return &AuthClient{
 		Client: Client{
-			apiKey:     res.AccessToken,
-			baseURL:    c.baseURL,
-			HTTPClient: c.HTTPClient,
+			apiKey:       res.AccessToken,
+			baseURL:      c.baseURL,
+			nameCacheTTL: c.nameCacheTTL,
+			HTTPClient:   c.HTTPClient,
 		},
 		accessToken:  res.AccessToken,
 		refreshToken: res.RefreshToken,
 	}, nil
 }
+
+// GetAuthClientWithToken returns a client authenticated with a pre-issued API token instead of a user and
+// password, ex GetAuthClientWithToken(ctx, StaticToken(token)). When the storage rejects the token and the
+// token source has no new one, the methods return an error matching ErrTokenExpired.
+func (c *Client) GetAuthClientWithToken(ctx context.Context, ts TokenSource) (*AuthClient, error) {
+	if ts == nil {
+		return nil, fmt.Errorf("nil token source")
+	}
+	token, err := ts.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("token source failed: %w", err)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("empty API token")
+	}
+
+	return &AuthClient{
+		Client: Client{
+			apiKey:       token,
+			baseURL:      c.baseURL,
+			nameCacheTTL: c.nameCacheTTL,
+			HTTPClient:   c.HTTPClient,
+		},
+		accessToken: token,
+		tokenSource: ts,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package goqsm
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// rotatingToken is a TokenSource whose token is replaced by the test.
+type rotatingToken struct {
+	mu    sync.Mutex
+	token string
+	calls int
+}
+
+func (r *rotatingToken) Token(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	return r.token, nil
+}
+
+func (r *rotatingToken) set(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = token
+}
+
+// handleWithToken registers a handler that accepts requests with the valid token only.
+func handleWithToken(fake *fakeServer, valid *string, mu *sync.Mutex, method, path string, h http.HandlerFunc) {
+	fake.handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ok := r.Header.Get("Authorization") == *valid
+		mu.Unlock()
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		h(w, r)
+	})
+}
+
+func TestAuthClientWithToken(t *testing.T) {
+	fake := newFakeServer(t)
+	var mu sync.Mutex
+	valid := "api-token-1"
+	handleWithToken(fake, &valid, &mu, http.MethodGet, "/rest/internal/cloud/containers/sc-1/vols/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []VolumeData{{ID: "vol-1", Name: "pvc-1"}})
+	})
+	handleWithToken(fake, &valid, &mu, http.MethodGet, "/rest/v2/dataTransfer/targets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, []TargetData{{ID: "tgt-1"}})
+	})
+	ctx := context.Background()
+
+	authClient, err := fake.client().GetAuthClientWithToken(ctx, StaticToken("api-token-1"))
+	if err != nil {
+		t.Fatalf("GetAuthClientWithToken failed: %v", err)
+	}
+	if vols, err := NewVolume(authClient).ListVolumes(ctx, "sc-1", ""); err != nil || len(*vols) != 1 {
+		t.Fatalf("ListVolumes failed: %v", err)
+	}
+	if tgts, err := NewTarget(authClient).ListTargets(ctx); err != nil || len(*tgts) != 1 {
+		t.Fatalf("ListTargets failed: %v", err)
+	}
+	if n := fake.count(http.MethodPost, "/auth/get"); n != 0 {
+		t.Fatalf("expected no login, got %d", n)
+	}
+
+	// the token expires
+	mu.Lock()
+	valid = "api-token-2"
+	mu.Unlock()
+	_, err = NewVolume(authClient).ListVolumes(ctx, "sc-1", "")
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+	if n := fake.count(http.MethodPost, "/auth/refresh"); n != 0 {
+		t.Fatalf("expected no token refresh, got %d", n)
+	}
+
+	if _, err := fake.client().GetAuthClientWithToken(ctx, StaticToken("")); err == nil {
+		t.Fatalf("expected an error for an empty token")
+	}
+	if _, err := fake.client().GetAuthClientWithToken(ctx, nil); err == nil {
+		t.Fatalf("expected an error for a nil token source")
+	}
+}
+
+func TestAuthClientTokenRotation(t *testing.T) {
+	fake := newFakeServer(t)
+	var mu sync.Mutex
+	valid := "api-token-1"
+	handleWithToken(fake, &valid, &mu, http.MethodPost, "/rest/internal/cloud/containers/sc-1/vols", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		writeJSON(w, http.StatusOK, VolumeData{ID: "vol-1", Name: r.Form.Get("name")})
+	})
+	ctx := context.Background()
+
+	ts := &rotatingToken{token: "api-token-1"}
+	authClient, err := fake.client().GetAuthClientWithToken(ctx, ts)
+	if err != nil {
+		t.Fatalf("GetAuthClientWithToken failed: %v", err)
+	}
+
+	// the token is rotated, the request is sent again with the body
+	mu.Lock()
+	valid = "api-token-2"
+	mu.Unlock()
+	ts.set("api-token-2")
+	vol, err := NewVolume(authClient).CreateVolume(ctx, "sc-1", "pvc-1", 1024, nil)
+	if err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+	if vol.Name != "pvc-1" || ts.calls != 2 {
+		t.Fatalf("unexpected volume %+v after %d token calls", *vol, ts.calls)
+	}
+
+	// the token source has no token, the request fails without sending an empty token
+	mu.Lock()
+	valid = "api-token-3"
+	mu.Unlock()
+	ts.set("")
+	_, err = NewVolume(authClient).CreateVolume(ctx, "sc-1", "pvc-2", 1024, nil)
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+	if n := fake.count(http.MethodPost, "/rest/internal/cloud/containers/sc-1/vols"); n != 3 {
+		t.Fatalf("expected 3 create requests, got %d", n)
+	}
+}
+
+func TestAuthClientRefreshToken(t *testing.T) {
+	fake := newFakeServer(t)
+	var mu sync.Mutex
+	valid := "fake-access-token"
+	handleWithToken(fake, &valid, &mu, http.MethodPost, "/rest/v2/system/users", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if len(body) == 0 {
+			writeError(w, http.StatusBadRequest, "empty body")
+			return
+		}
+		writeJSON(w, http.StatusOK, UserData{Name: "svc", Role: RoleOperator})
+	})
+	authClient := fake.authClient(t)
+
+	// the access token expired, the client refreshes it and sends the body again
+	authClient.setToken("expired-token")
+	if _, err := NewUser(authClient).CreateUser(context.Background(), &UserParam{Name: "svc", Password: "s3cret-pass", Role: RoleOperator}); err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if n := fake.count(http.MethodPost, "/auth/refresh"); n != 1 {
+		t.Fatalf("expected one token refresh, got %d", n)
+	}
+}
+
+func TestRedactToken(t *testing.T) {
+	if s := redactToken("api-token-1"); s != "******" {
+		t.Errorf("token is not masked: %s", s)
+	}
+	if s := redactToken(""); s != "" {
+		t.Errorf("unexpected masked empty token: %s", s)
+	}
+}
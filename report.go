@@ -0,0 +1,137 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"net/http"
+)
+
+// CapacityReport is the capacity usage and efficiency of a storage container, the array or a set of volumes.
+// Dedup is applied before compression, so LogicalUsedMB - DedupSavedMB - CompressSavedMB = PhysicalUsedMB.
+type CapacityReport struct {
+	SizeMB         uint64 `json:"sizeMB"`         // physical size, zero for a report of volumes
+	FreeMB         uint64 `json:"freeMB"`         // physical free space, zero for a report of volumes
+	ProvisionedMB  uint64 `json:"provisionedMB"`  // total size of the volumes
+	LogicalUsedMB  uint64 `json:"logicalUsedMB"`  // data written by the hosts
+	PhysicalUsedMB uint64 `json:"physicalUsedMB"` // space used by the data, without snapshots
+
+	CompressSavedMB uint64 `json:"compressSavedMB"` // space saved by compression
+	DedupSavedMB    uint64 `json:"dedupSavedMB"`    // space saved by dedup
+	SnapshotMB      uint64 `json:"snapshotMB"`      // space used by snapshots
+	VolumeCount     int    `json:"volumeCount"`
+}
+
+func ratio(a, b uint64) float64 {
+	if a == 0 || b == 0 {
+		return 1
+	}
+	return float64(a) / float64(b)
+}
+
+// deduplicatedMB returns the logical used space without the dedup savings.
+func (r *CapacityReport) deduplicatedMB() uint64 {
+	if r.DedupSavedMB > r.LogicalUsedMB {
+		return 0
+	}
+	return r.LogicalUsedMB - r.DedupSavedMB
+}
+
+// CompressionRatio returns the ratio of the deduplicated data to the physical used space, ex 2 for 2:1.
+// It is 1 without compression savings.
+func (r *CapacityReport) CompressionRatio() float64 {
+	return ratio(r.deduplicatedMB(), r.PhysicalUsedMB)
+}
+
+// DedupRatio returns the ratio of the logical used space to the deduplicated data, ex 1.5 for 1.5:1.
+// It is 1 without dedup savings.
+func (r *CapacityReport) DedupRatio() float64 {
+	return ratio(r.LogicalUsedMB, r.deduplicatedMB())
+}
+
+// DataReductionRatio returns the ratio of the logical used space to the physical used space,
+// the combined savings of compression and dedup.
+func (r *CapacityReport) DataReductionRatio() float64 {
+	return ratio(r.LogicalUsedMB, r.PhysicalUsedMB)
+}
+
+// SnapshotOverhead returns the space used by snapshots as a fraction of the physical used space.
+func (r *CapacityReport) SnapshotOverhead() float64 {
+	if r.PhysicalUsedMB == 0 {
+		return 0
+	}
+	return float64(r.SnapshotMB) / float64(r.PhysicalUsedMB)
+}
+
+// OverProvisionRatio returns the ratio of the provisioned size to the physical size, larger than 1
+// when thin volumes are overcommitted. It is 0 for a report of volumes.
+func (r *CapacityReport) OverProvisionRatio() float64 {
+	if r.SizeMB == 0 {
+		return 0
+	}
+	return float64(r.ProvisionedMB) / float64(r.SizeMB)
+}
+
+// Unbacked returns how much of the provisioned size exceeds the used and free space, the capacity
+// missing if the thin volumes were filled up. It is 0 for a report of volumes.
+func (r *CapacityReport) Unbacked() Capacity {
+	backed := r.PhysicalUsedMB + r.SnapshotMB + r.FreeMB
+	if r.SizeMB == 0 || r.ProvisionedMB <= backed {
+		return 0
+	}
+	return CapacityFromMB(r.ProvisionedMB - backed)
+}
+
+// AggregateCapacity sums the capacity of the volumes, ex returned by ListVolumes. A volume without
+// efficiency data counts its used space as both logical and physical used space.
+func AggregateCapacity(volumes []VolumeData) *CapacityReport {
+	r := &CapacityReport{VolumeCount: len(volumes)}
+	for _, v := range volumes {
+		logical, physical := v.LogicalUsedMB, v.PhysicalUsedMB
+		if logical == 0 && physical == 0 {
+			logical, physical = v.UsedMB, v.UsedMB
+		}
+		r.ProvisionedMB += v.SizeMB
+		r.LogicalUsedMB += logical
+		r.PhysicalUsedMB += physical
+		r.CompressSavedMB += v.CompressSavedMB
+		r.DedupSavedMB += v.DedupSavedMB
+		r.SnapshotMB += v.SnapshotMB
+	}
+
+	return r
+}
+
+// GetCapacityReport get the capacity report of a storage container
+func (c *ContainerOp) GetCapacityReport(ctx context.Context, scId string) (*CapacityReport, error) {
+	req, err := c.client.NewRequest(ctx, http.MethodGet, "/rest/internal/cloud/containers/"+scId+"/capacity", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := CapacityReport{}
+	if err := c.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetCapacityReport get the capacity report of the array, all storage containers together
+func (s *SystemOp) GetCapacityReport(ctx context.Context) (*CapacityReport, error) {
+	if s.authClient == nil {
+		return nil, ErrAuthRequired
+	}
+
+	req, err := s.authClient.NewRequest(ctx, http.MethodGet, "/rest/v2/system/capacity", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := CapacityReport{}
+	if err := s.authClient.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,80 @@
+package goqsm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestAggregateCapacity(t *testing.T) {
+	volumes := []VolumeData{
+		// 1000 MB written, dedup saves 200 MB, compression halves the rest
+		{ID: "v1", SizeMB: 4096, UsedMB: 1000, LogicalUsedMB: 1000, PhysicalUsedMB: 400, CompressSavedMB: 400, DedupSavedMB: 200, SnapshotMB: 100},
+		// without efficiency data
+		{ID: "v2", SizeMB: 2048, UsedMB: 600},
+	}
+
+	r := AggregateCapacity(volumes)
+	want := &CapacityReport{ProvisionedMB: 6144, LogicalUsedMB: 1600, PhysicalUsedMB: 1000, CompressSavedMB: 400, DedupSavedMB: 200, SnapshotMB: 100, VolumeCount: 2}
+	if !reflect.DeepEqual(r, want) {
+		t.Fatalf("unexpected report: %+v", *r)
+	}
+
+	ratios := []struct {
+		name      string
+		got, want float64
+	}{
+		{"compression", r.CompressionRatio(), 1.4},
+		{"dedup", r.DedupRatio(), 1600.0 / 1400},
+		{"data reduction", r.DataReductionRatio(), 1.6},
+		{"snapshot overhead", r.SnapshotOverhead(), 0.1},
+		{"over provision", r.OverProvisionRatio(), 0},
+	}
+	for _, tt := range ratios {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s ratio = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	empty := AggregateCapacity(nil)
+	if empty.CompressionRatio() != 1 || empty.DedupRatio() != 1 || empty.SnapshotOverhead() != 0 {
+		t.Errorf("unexpected ratios of an empty report: %+v", *empty)
+	}
+}
+
+func TestGetCapacityReport(t *testing.T) {
+	fake := newFakeServer(t)
+	scReport := CapacityReport{SizeMB: 10240, FreeMB: 4096, ProvisionedMB: 16384, LogicalUsedMB: 8192, PhysicalUsedMB: 5120, CompressSavedMB: 3072, SnapshotMB: 1024, VolumeCount: 8}
+	fake.handle(http.MethodGet, "/rest/internal/cloud/containers/sc-1/capacity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, scReport)
+	})
+	fake.handle(http.MethodGet, "/rest/v2/system/capacity", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, scReport)
+	})
+	authClient := fake.authClient(t)
+
+	r, err := NewContainer(authClient).GetCapacityReport(context.Background(), "sc-1")
+	if err != nil {
+		t.Fatalf("GetCapacityReport failed: %v", err)
+	}
+	if !reflect.DeepEqual(*r, scReport) {
+		t.Fatalf("unexpected report: %+v", *r)
+	}
+	if r.OverProvisionRatio() != 1.6 || r.CompressionRatio() != 1.6 || r.DedupRatio() != 1 {
+		t.Fatalf("unexpected ratios: over provision %v, compression %v, dedup %v", r.OverProvisionRatio(), r.CompressionRatio(), r.DedupRatio())
+	}
+	// 16Gi provisioned, 5Gi used, 1Gi snapshots and 4Gi free
+	if r.Unbacked() != 6*GiB {
+		t.Fatalf("unexpected unbacked capacity %v", r.Unbacked())
+	}
+
+	if _, err := NewAuthSystem(authClient).GetCapacityReport(context.Background()); err != nil {
+		t.Fatalf("GetCapacityReport of array failed: %v", err)
+	}
+	if _, err := NewSystem(fake.client()).GetCapacityReport(context.Background()); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("expected ErrAuthRequired, got %v", err)
+	}
+}
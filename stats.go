@@ -0,0 +1,187 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatsOp handles performance statistics related methods of the QSM storage.
+type StatsOp struct {
+	client *AuthClient
+}
+
+// StatsKind is the kind of object of performance statistics.
+type StatsKind string
+
+const (
+	StatsVolume     StatsKind = "volumes"     // keyed by VolumeData.ID
+	StatsTarget     StatsKind = "targets"     // keyed by TargetData.ID of an iSCSI target
+	StatsPort       StatsKind = "ports"       // keyed by PortData.Name
+	StatsController StatsKind = "controllers" // keyed by the controller index, ex "0"
+)
+
+// StatsInterval is the sampling interval of the performance history.
+// The storage keeps fine samples for a shorter time than coarse samples.
+type StatsInterval string
+
+const (
+	StatsInterval5s StatsInterval = "5s" // the last hour
+	StatsInterval1m StatsInterval = "1m" // the last day
+	StatsInterval1h StatsInterval = "1h" // the last month
+	StatsInterval1d StatsInterval = "1d" // the last year
+)
+
+// Duration returns the length of the sampling interval.
+func (i StatsInterval) Duration() time.Duration {
+	switch i {
+	case StatsInterval5s:
+		return 5 * time.Second
+	case StatsInterval1m:
+		return time.Minute
+	case StatsInterval1h:
+		return time.Hour
+	case StatsInterval1d:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Latency is an I/O latency in microseconds as reported by the storage.
+type Latency uint64
+
+// Duration returns the latency as a time.Duration.
+func (l Latency) Duration() time.Duration {
+	return time.Duration(l) * time.Microsecond
+}
+
+// LatencyPercentiles are the latencies of the I/Os completed in a sample.
+type LatencyPercentiles struct {
+	Avg Latency `json:"avg"`
+	P50 Latency `json:"p50"`
+	P95 Latency `json:"p95"`
+	P99 Latency `json:"p99"`
+	Max Latency `json:"max"`
+}
+
+// IOCounters are the counters of one direction of I/O in a sample.
+type IOCounters struct {
+	IOPS    float64            `json:"iops"`
+	MBps    float64            `json:"mbps"` // throughput in MB (MiB) per second
+	Latency LatencyPercentiles `json:"latency"`
+}
+
+// StatsSample is the performance of an object averaged over a sampling interval.
+// The counters of a network port are received (Read) and sent (Write) traffic.
+type StatsSample struct {
+	Time  time.Time  `json:"time"` // end of the sampling interval
+	Read  IOCounters `json:"read"`
+	Write IOCounters `json:"write"`
+}
+
+// IOPS returns the total read and write IOPS.
+func (s *StatsSample) IOPS() float64 {
+	return s.Read.IOPS + s.Write.IOPS
+}
+
+// MBps returns the total read and write throughput.
+func (s *StatsSample) MBps() float64 {
+	return s.Read.MBps + s.Write.MBps
+}
+
+// The response data of GetStatsHistory method
+type StatsData struct {
+	Kind     StatsKind     `json:"kind"`
+	ID       string        `json:"id"`
+	Interval StatsInterval `json:"interval"`
+	Samples  []StatsSample `json:"samples"` // in time order
+}
+
+// StatsQuery are the conditions of GetStatsHistory. Zero From and To do not limit the time range.
+type StatsQuery struct {
+	Interval StatsInterval // required
+	From     time.Time     // samples at or after From
+	To       time.Time     // samples before To
+}
+
+// NewStats returns performance statistics operation
+func NewStats(client *AuthClient) *StatsOp {
+	return &StatsOp{client}
+}
+
+func (k StatsKind) validate() error {
+	switch k {
+	case StatsVolume, StatsTarget, StatsPort, StatsController:
+		return nil
+	}
+	return fmt.Errorf("invalid stats kind %q", k)
+}
+
+func statsPath(kind StatsKind, id string) (string, error) {
+	if err := kind.validate(); err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("%s id is required", kind)
+	}
+	return "/rest/v2/stats/" + string(kind) + "/" + url.PathEscape(id), nil
+}
+
+// GetCurrentStats get the latest performance sample of an object, ex GetCurrentStats(ctx, StatsVolume, volId)
+func (s *StatsOp) GetCurrentStats(ctx context.Context, kind StatsKind, id string) (*StatsSample, error) {
+	path, err := statsPath(kind, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path+"/current", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := StatsSample{}
+	if err := s.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetStatsHistory get the performance samples of an object at the sampling interval of the query
+func (s *StatsOp) GetStatsHistory(ctx context.Context, kind StatsKind, id string, query StatsQuery) (*StatsData, error) {
+	path, err := statsPath(kind, id)
+	if err != nil {
+		return nil, err
+	}
+	if query.Interval.Duration() == 0 {
+		return nil, fmt.Errorf("invalid stats interval %q", query.Interval)
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return nil, fmt.Errorf("stats time range is empty")
+	}
+
+	params := url.Values{}
+	params.Add("interval", string(query.Interval))
+	if !query.From.IsZero() {
+		params.Add("from", query.From.UTC().Format(time.RFC3339))
+	}
+	if !query.To.IsZero() {
+		params.Add("to", query.To.UTC().Format(time.RFC3339))
+	}
+
+	req, err := s.client.NewRequest(ctx, http.MethodGet, path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := StatsData{}
+	if err := s.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,80 @@
+package goqsm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetCurrentStats(t *testing.T) {
+	fake := newFakeServer(t)
+	sample := StatsSample{
+		Time:  time.Date(2022, 6, 1, 12, 0, 5, 0, time.UTC),
+		Read:  IOCounters{IOPS: 1200, MBps: 75, Latency: LatencyPercentiles{Avg: 350, P50: 300, P95: 900, P99: 2500, Max: 8000}},
+		Write: IOCounters{IOPS: 800, MBps: 50, Latency: LatencyPercentiles{Avg: 500, P50: 450, P95: 1200, P99: 3000, Max: 9000}},
+	}
+	fake.handleJSON(http.MethodGet, "/rest/v2/stats/volumes/vol-1/current", sample)
+	fake.handleJSON(http.MethodGet, "/rest/v2/stats/ports/c0e1/current", sample)
+	statsOp := NewStats(fake.authClient(t))
+
+	res, err := statsOp.GetCurrentStats(context.Background(), StatsVolume, "vol-1")
+	if err != nil {
+		t.Fatalf("GetCurrentStats failed: %v", err)
+	}
+	if !reflect.DeepEqual(*res, sample) {
+		t.Fatalf("unexpected sample: %+v", *res)
+	}
+	if res.IOPS() != 2000 || res.MBps() != 125 || res.Write.Latency.P99.Duration() != 3*time.Millisecond {
+		t.Fatalf("unexpected counters: %v IOPS, %v MB/s, p99 %v", res.IOPS(), res.MBps(), res.Write.Latency.P99.Duration())
+	}
+
+	if _, err := statsOp.GetCurrentStats(context.Background(), StatsPort, "c0e1"); err != nil {
+		t.Fatalf("GetCurrentStats of port failed: %v", err)
+	}
+	if _, err := statsOp.GetCurrentStats(context.Background(), StatsKind("disks"), "d1"); err == nil {
+		t.Fatalf("expected an error for an invalid kind")
+	}
+	if _, err := statsOp.GetCurrentStats(context.Background(), StatsController, ""); err == nil {
+		t.Fatalf("expected an error for an empty id")
+	}
+}
+
+func TestGetStatsHistory(t *testing.T) {
+	fake := newFakeServer(t)
+	from := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+	fake.handle(http.MethodGet, "/rest/v2/stats/targets/tgt-1", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("interval") != "1h" || q.Get("from") != "2022-06-01T00:00:00Z" || q.Get("to") != "2022-06-01T03:00:00Z" {
+			writeError(w, http.StatusBadRequest, "unexpected query: "+r.URL.RawQuery)
+			return
+		}
+
+		data := StatsData{Kind: StatsTarget, ID: "tgt-1", Interval: StatsInterval1h}
+		for ts := from.Add(time.Hour); !ts.After(to); ts = ts.Add(time.Hour) {
+			data.Samples = append(data.Samples, StatsSample{Time: ts, Read: IOCounters{IOPS: 100}})
+		}
+		writeJSON(w, http.StatusOK, data)
+	})
+	statsOp := NewStats(fake.authClient(t))
+
+	res, err := statsOp.GetStatsHistory(context.Background(), StatsTarget, "tgt-1", StatsQuery{Interval: StatsInterval1h, From: from, To: to})
+	if err != nil {
+		t.Fatalf("GetStatsHistory failed: %v", err)
+	}
+	if res.Interval.Duration() != time.Hour || len(res.Samples) != 3 || !res.Samples[2].Time.Equal(to) {
+		t.Fatalf("unexpected history: %+v", *res)
+	}
+
+	invalid := []StatsQuery{
+		{},
+		{Interval: "10s"},
+		{Interval: StatsInterval5s, From: to, To: from},
+	}
+	expectInvalid(t, invalid, func(i int) error {
+		_, err := statsOp.GetStatsHistory(context.Background(), StatsTarget, "tgt-1", invalid[i])
+		return err
+	})
+}
@@ -4,12 +4,121 @@ package goqsm
 
 import (
 	"context"
+	"errors"
 	"net/http"
 )
 
 // SystemOp handles system related methods of the QSM storage.
 type SystemOp struct {
-	client *Client
+	client     *Client
+	authClient *AuthClient
+}
+
+// ErrAuthRequired is returned by the methods of a SystemOp created by NewSystem that need authentication.
+var ErrAuthRequired = errors.New("authentication required, use NewAuthSystem")
+
+// HealthStatus is the health status of a component.
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthUnknown  HealthStatus = "unknown"
+	HealthWarning  HealthStatus = "warning"
+	HealthCritical HealthStatus = "critical"
+)
+
+// rank orders the statuses by severity, unrecognized statuses rank as unknown.
+func (s HealthStatus) rank() int {
+	switch s {
+	case HealthOK:
+		return 0
+	case HealthWarning:
+		return 2
+	case HealthCritical:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Worse returns the more severe of two statuses, an unrecognized status is treated as HealthUnknown.
+func (s HealthStatus) Worse(other HealthStatus) HealthStatus {
+	worse := s
+	if other.rank() > s.rank() {
+		worse = other
+	}
+	if worse.rank() == HealthUnknown.rank() {
+		return HealthUnknown
+	}
+	return worse
+}
+
+// ComponentStatus is the health of a hardware component, ex a power supply or a fan.
+type ComponentStatus struct {
+	Name     string       `json:"name"`
+	Location string       `json:"location"` // ex "enclosure 0"
+	Status   HealthStatus `json:"status"`
+	Message  string       `json:"message"`
+}
+
+// ControllerHealth is the health of a controller.
+type ControllerHealth struct {
+	ID     int          `json:"id"`
+	State  string       `json:"state"` // ex "active", "standby", "failed" or "absent"
+	Status HealthStatus `json:"status"`
+}
+
+// TemperatureStatus is the health of a temperature sensor.
+type TemperatureStatus struct {
+	ComponentStatus
+	Celsius float64 `json:"celsius"`
+}
+
+// BatteryStatus is the health of a battery backup unit.
+type BatteryStatus struct {
+	ComponentStatus
+	ChargePercent int `json:"chargePercent"`
+}
+
+// CacheStatus is the health of the controller cache.
+type CacheStatus struct {
+	Mode     string       `json:"mode"` // "writeBack" or "writeThrough"
+	Mirrored bool         `json:"mirrored"`
+	Status   HealthStatus `json:"status"`
+}
+
+// The response data of GetHealth method
+type HealthData struct {
+	Controllers   []ControllerHealth  `json:"controllers"`
+	PowerSupplies []ComponentStatus   `json:"powerSupplies"`
+	Fans          []ComponentStatus   `json:"fans"`
+	Temperatures  []TemperatureStatus `json:"temperatures"`
+	Batteries     []BatteryStatus     `json:"batteries"`
+	Cache         CacheStatus         `json:"cache"`
+
+	// Severity is the worst status of all components.
+	Severity HealthStatus `json:"-"`
+}
+
+func (h *HealthData) rollup() HealthStatus {
+	severity := HealthOK
+	for _, c := range h.Controllers {
+		severity = severity.Worse(c.Status)
+	}
+	for _, c := range h.PowerSupplies {
+		severity = severity.Worse(c.Status)
+	}
+	for _, c := range h.Fans {
+		severity = severity.Worse(c.Status)
+	}
+	for _, c := range h.Temperatures {
+		severity = severity.Worse(c.Status)
+	}
+	for _, c := range h.Batteries {
+		severity = severity.Worse(c.Status)
+	}
+
+	return severity.Worse(h.Cache.Status)
 }
 
 // The response data of GetAbout method
@@ -26,9 +135,14 @@ type AboutData struct {
 	Wwn          string `json:"wwn"`
 }
 
-// NewSystem returns system operation
+// NewSystem returns system operation without authentication
 func NewSystem(client *Client) *SystemOp {
-	return &SystemOp{client}
+	return &SystemOp{client: client}
+}
+
+// NewAuthSystem returns system operation with authentication
+func NewAuthSystem(client *AuthClient) *SystemOp {
+	return &SystemOp{client: &client.Client, authClient: client}
 }
 
 // GetAbout get system information without authentication
@@ -45,3 +159,23 @@ func (s *SystemOp) GetAbout(ctx context.Context) (*AboutData, error) {
 
 	return &res, nil
 }
+
+// GetHealth get the health of controllers, power supplies, fans, temperatures, batteries and cache
+func (s *SystemOp) GetHealth(ctx context.Context) (*HealthData, error) {
+	if s.authClient == nil {
+		return nil, ErrAuthRequired
+	}
+
+	req, err := s.authClient.NewRequest(ctx, http.MethodGet, "/rest/v2/system/health", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := HealthData{}
+	if err := s.authClient.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+	res.Severity = res.rollup()
+
+	return &res, nil
+}
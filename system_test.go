@@ -2,7 +2,9 @@ package goqsm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 )
 
@@ -10,10 +12,13 @@ var ctx context.Context
 
 func TestSystem(t *testing.T) {
 	fmt.Println("------------TestSystem--------------")
+	skipIfNoTestConf(t)
 
 	ctx = context.Background()
 
 	getAboutTest(t)
+
+	getHealthTest(t)
 }
 
 func getAboutTest(t *testing.T) {
@@ -26,3 +31,58 @@ func getAboutTest(t *testing.T) {
 
 	fmt.Println("getAboutTest Leave")
 }
+
+func getHealthTest(t *testing.T) {
+	fmt.Println("getHealthTest Enter")
+
+	health, err := testConf.authSysOp.GetHealth(ctx)
+	if err != nil {
+		t.Fatalf("GetHealth failed: %v", err)
+	}
+	fmt.Printf("  Health severity: %s\n", health.Severity)
+
+	fmt.Println("getHealthTest Leave")
+}
+
+func TestGetHealth(t *testing.T) {
+	fake := newFakeServer(t)
+	health := HealthData{
+		Controllers:   []ControllerHealth{{ID: 0, State: "active", Status: HealthOK}, {ID: 1, State: "standby", Status: HealthOK}},
+		PowerSupplies: []ComponentStatus{{Name: "PSU1", Status: HealthOK}, {Name: "PSU2", Status: HealthOK}},
+		Fans:          []ComponentStatus{{Name: "FAN1", Status: HealthOK}},
+		Temperatures:  []TemperatureStatus{{ComponentStatus{Name: "CPU", Status: HealthOK}, 45}},
+		Batteries:     []BatteryStatus{{ComponentStatus{Name: "BBU", Status: HealthOK}, 100}},
+		Cache:         CacheStatus{Mode: "writeBack", Mirrored: true, Status: HealthOK},
+	}
+	fake.handleJSON(http.MethodGet, "/rest/v2/system/health", &health)
+	ctx := context.Background()
+
+	if _, err := NewSystem(fake.client()).GetHealth(ctx); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("GetHealth without authentication expected ErrAuthRequired, got %v", err)
+	}
+
+	systemOp := NewAuthSystem(fake.authClient(t))
+	res, err := systemOp.GetHealth(ctx)
+	if err != nil {
+		t.Fatalf("GetHealth failed: %v", err)
+	}
+	if res.Severity != HealthOK || res.Temperatures[0].Celsius != 45 || res.Batteries[0].Name != "BBU" {
+		t.Fatalf("unexpected health: %+v", *res)
+	}
+
+	health.Fans[0].Status = HealthWarning
+	health.Batteries[0].Status = "charging"
+	if res, _ = systemOp.GetHealth(ctx); res.Severity != HealthWarning {
+		t.Fatalf("expected warning severity, got %s", res.Severity)
+	}
+
+	health.Controllers[1] = ControllerHealth{ID: 1, State: "failed", Status: HealthCritical}
+	if res, _ = systemOp.GetHealth(ctx); res.Severity != HealthCritical {
+		t.Fatalf("expected critical severity, got %s", res.Severity)
+	}
+
+	health = HealthData{Cache: CacheStatus{Status: HealthOK}, Batteries: health.Batteries}
+	if res, _ = systemOp.GetHealth(ctx); res.Severity != HealthUnknown {
+		t.Fatalf("expected unknown severity, got %s", res.Severity)
+	}
+}
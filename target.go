@@ -5,7 +5,14 @@ package goqsm
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // TargetOp handles target related methods of the QSM storage.
@@ -13,8 +20,94 @@ type TargetOp struct {
 	client *AuthClient
 }
 
+// TargetType is the transport type of a target.
+type TargetType string
+
+const (
+	TargetTypeIscsi TargetType = "iSCSI"
+	TargetTypeFC    TargetType = "FC"
+)
+
+// Fc are the Fibre Channel settings of a FC target.
+type Fc struct {
+	Ports []string `json:"ports,omitempty"` // controller FC ports, ex "c0fc1"
+}
+
+// FcTargetData are the Fibre Channel details of a FC target.
+type FcTargetData struct {
+	Wwnn  string   `json:"wwnn"`
+	Ports []string `json:"ports"`
+	Wwpns []string `json:"wwpns"` // target WWPNs of the ports
+}
+
 type Iscsi struct {
-	Eths []string `json:"eths"`
+	Eths  []string      `json:"eths"`
+	Alias string        `json:"alias,omitempty"`
+	Chap  *ChapSettings `json:"chap,omitempty"`
+}
+
+// ChapSecret is a CHAP secret, it is masked when printed with the fmt package.
+type ChapSecret string
+
+func (s ChapSecret) String() string {
+	return "******"
+}
+
+// Format masks the secret for every verb of the fmt package.
+func (s ChapSecret) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, `"******"`)
+		return
+	}
+	io.WriteString(f, s.String())
+}
+
+const (
+	chapSecretMinLen = 12
+	chapSecretMaxLen = 16
+)
+
+// ChapSettings are the CHAP authentication settings of an iSCSI target.
+// Username and Secret enable one-way CHAP, MutualUsername and MutualSecret additionally enable mutual CHAP.
+// An empty ChapSettings disables CHAP.
+type ChapSettings struct {
+	Username       string     `json:"username"`
+	Secret         ChapSecret `json:"secret"`
+	MutualUsername string     `json:"mutualUsername,omitempty"`
+	MutualSecret   ChapSecret `json:"mutualSecret,omitempty"`
+}
+
+func (c ChapSettings) String() string {
+	return fmt.Sprintf("{Username:%s Secret:%s MutualUsername:%s MutualSecret:%s}", c.Username, c.Secret, c.MutualUsername, c.MutualSecret)
+}
+
+// Validate checks the CHAP settings before they are sent to the storage. A nil settings is valid.
+func (c *ChapSettings) Validate() error {
+	if c == nil || *c == (ChapSettings{}) {
+		return nil
+	}
+
+	if c.Username == "" {
+		return fmt.Errorf("CHAP username is required")
+	}
+	if len(c.Secret) < chapSecretMinLen || len(c.Secret) > chapSecretMaxLen {
+		return fmt.Errorf("CHAP secret must be %d to %d characters", chapSecretMinLen, chapSecretMaxLen)
+	}
+
+	if c.MutualUsername == "" && c.MutualSecret == "" {
+		return nil
+	}
+	if c.MutualUsername == "" {
+		return fmt.Errorf("mutual CHAP username is required")
+	}
+	if len(c.MutualSecret) < chapSecretMinLen || len(c.MutualSecret) > chapSecretMaxLen {
+		return fmt.Errorf("mutual CHAP secret must be %d to %d characters", chapSecretMinLen, chapSecretMaxLen)
+	}
+	if c.MutualSecret == c.Secret {
+		return fmt.Errorf("mutual CHAP secret must differ from CHAP secret")
+	}
+
+	return nil
 }
 
 type Host struct {
@@ -27,22 +120,250 @@ type HostGroup struct {
 }
 
 type CreateTargetParam struct {
-	Type       string `json:"type"`
+	Type       TargetType `json:"type"`
 	Iscsi      `json:"iscsi"`
+	Fc         *Fc         `json:"fc,omitempty"`
 	HostGroups []HostGroup `json:"hostGroup"`
 }
 
+// MarshalJSON leaves the iSCSI settings out of the request of a FC target.
+func (p CreateTargetParam) MarshalJSON() ([]byte, error) {
+	type param CreateTargetParam
+	if p.Type != TargetTypeFC {
+		return json.Marshal(param(p))
+	}
+	return json.Marshal(struct {
+		param
+		Iscsi *Iscsi `json:"iscsi,omitempty"`
+	}{param: param(p)})
+}
+
+// validateFcHosts checks that the hosts of FC host groups are WWPNs or AnyInitiator.
+func validateFcHosts(groups []HostGroup) error {
+	for _, group := range groups {
+		for _, host := range group.Hosts {
+			for _, name := range host.Name {
+				if name != AnyInitiator && !wwpnRegexp.MatchString(strings.ToLower(name)) {
+					return fmt.Errorf("invalid FC host WWPN %q", name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the parameter before it is sent to the storage.
+func (p *CreateTargetParam) Validate() error {
+	switch p.Type {
+	case TargetTypeIscsi:
+		if len(p.Eths) == 0 {
+			return fmt.Errorf("iSCSI target requires ethernet ports")
+		}
+		if p.Fc != nil {
+			return fmt.Errorf("iSCSI target with FC settings")
+		}
+		if err := p.Chap.Validate(); err != nil {
+			return err
+		}
+	case TargetTypeFC:
+		if p.Fc == nil || len(p.Fc.Ports) == 0 {
+			return fmt.Errorf("FC target requires FC ports")
+		}
+		if len(p.Eths) != 0 || p.Chap != nil {
+			return fmt.Errorf("FC target with iSCSI settings")
+		}
+		if err := validateFcHosts(p.HostGroups); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid target type %q", p.Type)
+	}
+
+	for _, group := range p.HostGroups {
+		if err := validateHosts(group.Hosts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateTargetParam is the parameter of UpdateTarget, nil or empty fields are left unchanged.
+// Fc updates the ports of a FC target, the hosts of its host groups are WWPNs.
+type UpdateTargetParam struct {
+	Iscsi      *Iscsi      `json:"iscsi,omitempty"`
+	Fc         *Fc         `json:"fc,omitempty"`
+	HostGroups []HostGroup `json:"hostGroup,omitempty"`
+}
+
+// Validate checks the parameter before it is sent to the storage.
+func (p *UpdateTargetParam) Validate() error {
+	if p.Iscsi != nil && p.Fc != nil {
+		return fmt.Errorf("both iSCSI and FC settings")
+	}
+	if p.Iscsi != nil {
+		if err := p.Iscsi.Chap.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.Fc != nil {
+		if len(p.Fc.Ports) == 0 {
+			return fmt.Errorf("FC target requires FC ports")
+		}
+		if err := validateFcHosts(p.HostGroups); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range p.HostGroups {
+		if err := validateHosts(group.Hosts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LunData is a LUN which maps a volume to a target.
+type LunData struct {
+	Lun      int    `json:"lun"`
+	VolID    string `json:"volId"`
+	NaaID    string `json:"naaId"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+const (
+	// AutoLun lets MapLun allocate the lowest free LUN number.
+	AutoLun = -1
+	// MaxLun is the largest LUN number of a target.
+	MaxLun = 255
+)
+
+// LunConflictError is returned by MapLun when the LUN number is in use or the volume is already mapped to the target.
+type LunConflictError struct {
+	TgtID string
+	Lun   int    // the conflicting LUN number
+	VolID string // the volume mapped to Lun
+}
+
+func (e *LunConflictError) Error() string {
+	if e.VolID == "" {
+		return fmt.Sprintf("LUN %d of target %s is already in use", e.Lun, e.TgtID)
+	}
+	return fmt.Sprintf("LUN %d of target %s is already mapped to volume %s", e.Lun, e.TgtID, e.VolID)
+}
+
+// lunConflict returns the mapping conflicting with mapping the volume to the LUN, or nil.
+func lunConflict(tgtId, volId string, lun int, luns []LunData) *LunConflictError {
+	for _, l := range luns {
+		if l.VolID == volId || l.Lun == lun {
+			return &LunConflictError{TgtID: tgtId, Lun: l.Lun, VolID: l.VolID}
+		}
+	}
+	return nil
+}
+
+// TargetStatus is the state of a target.
+type TargetStatus string
+
+const (
+	TargetOnline   TargetStatus = "online"
+	TargetOffline  TargetStatus = "offline"
+	TargetDegraded TargetStatus = "degraded"
+)
+
+// DefaultIscsiPort is the TCP port of an iSCSI portal when the storage does not report one.
+const DefaultIscsiPort = 3260
+
+// Portal is an iSCSI portal of a target on an ethernet port.
+type Portal struct {
+	Eth  string `json:"eth"`
+	IP   string `json:"ip"`
+	Port int    `json:"port"`
+}
+
+// Address returns the portal address in "IP:port" form, IPv6 addresses are bracketed.
+func (p Portal) Address() string {
+	port := p.Port
+	if port == 0 {
+		port = DefaultIscsiPort
+	}
+	return net.JoinHostPort(p.IP, strconv.Itoa(port))
+}
+
+// IscsiTargetData are the iSCSI details of an iSCSI target.
+type IscsiTargetData struct {
+	Iqn     string   `json:"iqn"`
+	Name    string   `json:"name"`
+	Alias   string   `json:"alias"`
+	Eths    []string `json:"eths"`
+	Portals []Portal `json:"portals"` // a portal per ethernet port in Eths
+}
+
 type TargetData struct {
-	ID    string `json:"id"`
-	Type  string `json:"type"`
-	Iscsi struct {
-		Iqn   string      `json:"iqn"`
-		Name  string      `json:"name"`
-		Alias interface{} `json:"alias"`
-		Eths  []string    `json:"eths"`
-	} `json:"iscsi"`
-	Luns       []interface{} `json:"luns"`
-	HostGroups []HostGroup   `json:"hostGroup"`
+	ID           string          `json:"id"`
+	Type         TargetType      `json:"type"`
+	Status       TargetStatus    `json:"status"`
+	SessionCount int             `json:"sessionCount"` // number of logged in sessions
+	Iscsi        IscsiTargetData `json:"iscsi"`
+	Fc           *FcTargetData   `json:"fc,omitempty"`
+	Luns         []LunData       `json:"luns"`
+	HostGroups   []HostGroup     `json:"hostGroup"`
+}
+
+// The response data of ListSessions method
+type SessionData struct {
+	ID           string    `json:"id"`
+	InitiatorIqn string    `json:"initiatorIqn"`
+	SourceIP     string    `json:"sourceIp"`
+	Connections  int       `json:"connections"`
+	LoginTime    time.Time `json:"loginTime"`
+}
+
+// IscsiLogin is a portal and IQN pair for a host-side iSCSI login, ex
+// "iscsiadm -m node -T <Iqn> -p <Portal> --login".
+type IscsiLogin struct {
+	Portal string
+	Iqn    string
+}
+
+// IscsiLogins returns the portal and IQN pairs of an iSCSI target, a pair per portal with an IP address.
+func (t *TargetData) IscsiLogins() ([]IscsiLogin, error) {
+	if t.Type != TargetTypeIscsi {
+		return nil, fmt.Errorf("target %s is not an iSCSI target", t.ID)
+	}
+
+	logins := []IscsiLogin{}
+	for _, p := range t.Iscsi.Portals {
+		if p.IP == "" {
+			continue
+		}
+		logins = append(logins, IscsiLogin{Portal: p.Address(), Iqn: t.Iscsi.Iqn})
+	}
+	if len(logins) == 0 {
+		return nil, fmt.Errorf("target %s has no portal", t.ID)
+	}
+
+	return logins, nil
+}
+
+// ZoningWwpns returns the target WWPNs and the host WWPNs of a FC target for switch zoning.
+// Wildcard hosts are skipped.
+func (t *TargetData) ZoningWwpns() (targetWwpns []string, hostWwpns []string) {
+	if t.Fc != nil {
+		targetWwpns = append(targetWwpns, t.Fc.Wwpns...)
+	}
+	for _, group := range t.HostGroups {
+		for _, host := range group.Hosts {
+			for _, name := range host.Name {
+				if name != AnyInitiator {
+					hostWwpns = append(hostWwpns, name)
+				}
+			}
+		}
+	}
+
+	return targetWwpns, hostWwpns
 }
 
 // NewTarget returns volume operation
@@ -50,8 +371,12 @@ func NewTarget(client *AuthClient) *TargetOp {
 	return &TargetOp{client}
 }
 
-// CreateTarget create a target on a storage server
+// CreateTarget create an iSCSI or FC target on a storage server
 func (v *TargetOp) CreateTarget(ctx context.Context, param *CreateTargetParam) (*TargetData, error) {
+	if err := param.Validate(); err != nil {
+		return nil, err
+	}
+
 	rawdata, _ := json.Marshal(param)
 	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/v2/dataTransfer/targets", string(rawdata))
 	if err != nil {
@@ -65,3 +390,184 @@ func (v *TargetOp) CreateTarget(ctx context.Context, param *CreateTargetParam) (
 
 	return &res, nil
 }
+
+// ListTargets list all targets on a storage server
+func (v *TargetOp) ListTargets(ctx context.Context) (*[]TargetData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/v2/dataTransfer/targets", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []TargetData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetTarget get a target with tgtId, an error matching ErrNotFound is returned if the target does not exist
+func (v *TargetOp) GetTarget(ctx context.Context, tgtId string) (*TargetData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgtId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := TargetData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UpdateTarget update the ethernet ports, alias, CHAP settings, FC ports or host groups of a target
+func (v *TargetOp) UpdateTarget(ctx context.Context, tgtId string, param *UpdateTargetParam) (*TargetData, error) {
+	if err := param.Validate(); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(param)
+	req, err := v.client.NewRequest(ctx, http.MethodPatch, "/rest/v2/dataTransfer/targets/"+tgtId, string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := TargetData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// DeleteTarget delete a target from a storage server
+func (v *TargetOp) DeleteTarget(ctx context.Context, tgtId string) error {
+	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/v2/dataTransfer/targets/"+tgtId, nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListLuns list all LUNs of a target
+func (v *TargetOp) ListLuns(ctx context.Context, tgtId string) (*[]LunData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgtId+"/luns", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []LunData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// MapLun map a volume to a target with the LUN number, or with the lowest free number if lun is AutoLun.
+// LunConflictError is returned if the LUN number is in use or the volume is already mapped to the target.
+func (v *TargetOp) MapLun(ctx context.Context, tgtId, volId string, lun int, readOnly bool) (*LunData, error) {
+	if lun != AutoLun && (lun < 0 || lun > MaxLun) {
+		return nil, fmt.Errorf("invalid LUN %d: must be between 0 and %d", lun, MaxLun)
+	}
+
+	luns, err := v.ListLuns(ctx, tgtId)
+	if err != nil {
+		return nil, err
+	}
+
+	if conflictErr := lunConflict(tgtId, volId, lun, *luns); conflictErr != nil {
+		return nil, conflictErr
+	}
+	used := map[int]bool{}
+	for _, l := range *luns {
+		used[l.Lun] = true
+	}
+
+	if lun == AutoLun {
+		for i := 0; i <= MaxLun; i++ {
+			if !used[i] {
+				lun = i
+				break
+			}
+		}
+		if lun == AutoLun {
+			return nil, fmt.Errorf("no free LUN on target %s", tgtId)
+		}
+	}
+
+	rawdata, _ := json.Marshal(LunData{Lun: lun, VolID: volId, ReadOnly: readOnly})
+	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/v2/dataTransfer/targets/"+tgtId+"/luns", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := LunData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		// A concurrent mapping took the LUN or the volume after ListLuns
+		apiErr := &APIError{}
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			if luns, listErr := v.ListLuns(ctx, tgtId); listErr == nil {
+				if conflictErr := lunConflict(tgtId, volId, lun, *luns); conflictErr != nil {
+					return nil, conflictErr
+				}
+			}
+			return nil, &LunConflictError{TgtID: tgtId, Lun: lun}
+		}
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UnmapLun unmap a LUN from a target
+func (v *TargetOp) UnmapLun(ctx context.Context, tgtId string, lun int) error {
+	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/v2/dataTransfer/targets/"+tgtId+"/luns/"+strconv.Itoa(lun), nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListSessions list the iSCSI sessions of the initiators logged in to a target
+func (v *TargetOp) ListSessions(ctx context.Context, tgtId string) (*[]SessionData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgtId+"/sessions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []SessionData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// DisconnectSession forcibly disconnect a session from a target, ex a stale session before a volume is unmapped
+func (v *TargetOp) DisconnectSession(ctx context.Context, tgtId, sessionId string) error {
+	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/v2/dataTransfer/targets/"+tgtId+"/sessions/"+sessionId, nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,23 +2,32 @@ package goqsm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestTarget(t *testing.T) {
 	fmt.Println("------------TestTarget--------------")
+	skipIfNoTestConf(t)
 
 	ctx = context.Background()
 
-	// createTargetTest(t)
+	targetLifecycleTest(t)
 }
 
-func createTargetTest(t *testing.T) {
-	fmt.Println("createTargetTest Enter")
+func targetLifecycleTest(t *testing.T) {
+	fmt.Println("targetLifecycleTest Enter")
 
 	param := &CreateTargetParam{
-		Type: "iSCSI",
+		Type: TargetTypeIscsi,
 		Iscsi: Iscsi{
 			Eths: []string{"c0e1", "c0e2"},
 		},
@@ -35,5 +44,553 @@ func createTargetTest(t *testing.T) {
 	}
 	fmt.Printf("  A Target was created. %+v\n", tgt)
 
-	fmt.Println("createTargetTest Leave")
+	_, err = testConf.targetOp.GetTarget(ctx, tgt.ID)
+	if err != nil {
+		t.Fatalf("GetTarget failed: %v", err)
+	}
+
+	updateParam := &UpdateTargetParam{Iscsi: &Iscsi{Alias: "gotest-alias"}}
+	tgt, err = testConf.targetOp.UpdateTarget(ctx, tgt.ID, updateParam)
+	if err != nil {
+		t.Fatalf("UpdateTarget failed: %v", err)
+	}
+	fmt.Printf("  A Target was updated. %+v\n", tgt)
+
+	err = testConf.targetOp.DeleteTarget(ctx, tgt.ID)
+	if err != nil {
+		t.Fatalf("DeleteTarget failed: %v", err)
+	}
+	fmt.Printf("  A Target was deleted. Id:%s\n", tgt.ID)
+
+	fmt.Println("targetLifecycleTest Leave")
+}
+
+// fakeTargets serves the target APIs on a fake server with an in-memory target table.
+type fakeTargets struct {
+	mu      sync.Mutex
+	targets map[string]*TargetData
+	nextId  int
+	created map[string]json.RawMessage // the body of the last create request
+}
+
+func newFakeTargets(fake *fakeServer) *fakeTargets {
+	ft := &fakeTargets{targets: map[string]*TargetData{}}
+
+	fake.handle(http.MethodPost, "/rest/v2/dataTransfer/targets", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		param := CreateTargetParam{}
+		if err := json.Unmarshal(body, &param); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ft.mu.Lock()
+		defer ft.mu.Unlock()
+		json.Unmarshal(body, &ft.created)
+		ft.nextId++
+		id := fmt.Sprintf("tgt%d", ft.nextId)
+		tgt := &TargetData{ID: id, Type: param.Type, Status: TargetOnline, HostGroups: param.HostGroups}
+		tgt.Iscsi.Iqn = "iqn.2004-08.com.qsan:" + id
+		tgt.Iscsi.Eths = param.Eths
+		tgt.Iscsi.Alias = param.Alias
+		for i, eth := range param.Eths {
+			tgt.Iscsi.Portals = append(tgt.Iscsi.Portals, Portal{Eth: eth, IP: fmt.Sprintf("192.168.10.%d", i+1), Port: DefaultIscsiPort})
+		}
+		if param.Fc != nil {
+			tgt.Fc = &FcTargetData{Wwnn: "2000001378abcdef", Ports: param.Fc.Ports}
+			for i := range param.Fc.Ports {
+				tgt.Fc.Wwpns = append(tgt.Fc.Wwpns, fmt.Sprintf("21%02x001378abcdef", i))
+			}
+		}
+		ft.targets[id] = tgt
+		ft.handleTarget(fake, id)
+		writeJSON(w, http.StatusOK, tgt)
+	})
+	fake.handle(http.MethodGet, "/rest/v2/dataTransfer/targets", func(w http.ResponseWriter, r *http.Request) {
+		ft.mu.Lock()
+		defer ft.mu.Unlock()
+		res := []TargetData{}
+		for i := 1; i <= ft.nextId; i++ {
+			if tgt, ok := ft.targets[fmt.Sprintf("tgt%d", i)]; ok {
+				res = append(res, *tgt)
+			}
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+
+	return ft
+}
+
+func (ft *fakeTargets) handleTarget(fake *fakeServer, id string) {
+	path := "/rest/v2/dataTransfer/targets/" + id
+	withTarget := func(h func(w http.ResponseWriter, r *http.Request, tgt *TargetData)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ft.mu.Lock()
+			defer ft.mu.Unlock()
+			tgt, ok := ft.targets[id]
+			if !ok {
+				writeError(w, http.StatusNotFound, "target not found")
+				return
+			}
+			h(w, r, tgt)
+		}
+	}
+
+	fake.handle(http.MethodGet, path, withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+		writeJSON(w, http.StatusOK, tgt)
+	}))
+	fake.handle(http.MethodPatch, path, withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+		param := UpdateTargetParam{}
+		if err := json.NewDecoder(r.Body).Decode(&param); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if param.Iscsi != nil {
+			if len(param.Iscsi.Eths) > 0 {
+				tgt.Iscsi.Eths = param.Iscsi.Eths
+			}
+			if param.Iscsi.Alias != "" {
+				tgt.Iscsi.Alias = param.Iscsi.Alias
+			}
+		}
+		if param.Fc != nil && tgt.Fc != nil {
+			tgt.Fc.Ports = param.Fc.Ports
+			tgt.Fc.Wwpns = nil
+			for i := range param.Fc.Ports {
+				tgt.Fc.Wwpns = append(tgt.Fc.Wwpns, fmt.Sprintf("21%02x001378abcdef", i))
+			}
+		}
+		if len(param.HostGroups) > 0 {
+			tgt.HostGroups = param.HostGroups
+		}
+		writeJSON(w, http.StatusOK, tgt)
+	}))
+	fake.handle(http.MethodDelete, path, withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+		delete(ft.targets, id)
+		writeJSON(w, http.StatusOK, EmptyData{})
+	}))
+
+	fake.handle(http.MethodGet, path+"/luns", withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+		writeJSON(w, http.StatusOK, append([]LunData{}, tgt.Luns...))
+	}))
+	fake.handle(http.MethodPost, path+"/luns", withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+		lun := LunData{}
+		if err := json.NewDecoder(r.Body).Decode(&lun); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		for _, l := range tgt.Luns {
+			if l.Lun == lun.Lun || l.VolID == lun.VolID {
+				writeError(w, http.StatusConflict, "LUN conflict")
+				return
+			}
+		}
+		lun.NaaID = "naa.6" + lun.VolID
+		tgt.Luns = append(tgt.Luns, lun)
+
+		lunPath := fmt.Sprintf("%s/luns/%d", path, lun.Lun)
+		fake.handle(http.MethodDelete, lunPath, withTarget(func(w http.ResponseWriter, r *http.Request, tgt *TargetData) {
+			for i, l := range tgt.Luns {
+				if fmt.Sprintf("%s/luns/%d", path, l.Lun) == lunPath {
+					tgt.Luns = append(tgt.Luns[:i], tgt.Luns[i+1:]...)
+					writeJSON(w, http.StatusOK, EmptyData{})
+					return
+				}
+			}
+			writeError(w, http.StatusNotFound, "LUN not found")
+		}))
+		writeJSON(w, http.StatusOK, lun)
+	}))
+}
+
+func TestTargetLifecycle(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeTargets(fake)
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	param := &CreateTargetParam{
+		Type:       TargetTypeIscsi,
+		Iscsi:      Iscsi{Eths: []string{"c0e1", "c1e1"}},
+		HostGroups: []HostGroup{{Name: "group1", Hosts: []Host{{Name: []string{"*"}}}}},
+	}
+	tgt, err := targetOp.CreateTarget(ctx, param)
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+
+	tgts, err := targetOp.ListTargets(ctx)
+	if err != nil {
+		t.Fatalf("ListTargets failed: %v", err)
+	}
+	if len(*tgts) != 1 || (*tgts)[0].ID != tgt.ID {
+		t.Fatalf("unexpected targets: %+v", *tgts)
+	}
+
+	updateParam := &UpdateTargetParam{Iscsi: &Iscsi{Eths: []string{"c0e2"}, Alias: "k8s"}}
+	if _, err := targetOp.UpdateTarget(ctx, tgt.ID, updateParam); err != nil {
+		t.Fatalf("UpdateTarget failed: %v", err)
+	}
+	res, err := targetOp.GetTarget(ctx, tgt.ID)
+	if err != nil {
+		t.Fatalf("GetTarget failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Iscsi.Eths, []string{"c0e2"}) || res.Iscsi.Alias != "k8s" || len(res.HostGroups) != 1 {
+		t.Fatalf("target was not updated: %+v", *res)
+	}
+
+	if err := targetOp.DeleteTarget(ctx, tgt.ID); err != nil {
+		t.Fatalf("DeleteTarget failed: %v", err)
+	}
+
+	_, err = targetOp.GetTarget(ctx, tgt.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetTarget of a deleted target expected ErrNotFound, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "target not found" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if err := targetOp.DeleteTarget(ctx, tgt.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("DeleteTarget of a deleted target expected ErrNotFound, got %v", err)
+	}
+	if _, err := targetOp.UpdateTarget(ctx, tgt.ID, updateParam); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("UpdateTarget of a deleted target expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLunMapping(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeTargets(fake)
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	tgt, err := targetOp.CreateTarget(ctx, &CreateTargetParam{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}}})
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+
+	lun0, err := targetOp.MapLun(ctx, tgt.ID, "v0", AutoLun, false)
+	if err != nil {
+		t.Fatalf("MapLun failed: %v", err)
+	}
+	lun2, err := targetOp.MapLun(ctx, tgt.ID, "v2", 2, true)
+	if err != nil {
+		t.Fatalf("MapLun failed: %v", err)
+	}
+	lun1, err := targetOp.MapLun(ctx, tgt.ID, "v1", AutoLun, false)
+	if err != nil {
+		t.Fatalf("MapLun failed: %v", err)
+	}
+	if lun0.Lun != 0 || lun1.Lun != 1 || lun2.Lun != 2 || !lun2.ReadOnly || lun0.NaaID == "" {
+		t.Fatalf("unexpected LUNs: %+v %+v %+v", *lun0, *lun1, *lun2)
+	}
+
+	var conflictErr *LunConflictError
+	_, err = targetOp.MapLun(ctx, tgt.ID, "v3", 2, false)
+	if !errors.As(err, &conflictErr) || conflictErr.Lun != 2 || conflictErr.VolID != "v2" {
+		t.Fatalf("expected LunConflictError of LUN 2, got %v", err)
+	}
+	_, err = targetOp.MapLun(ctx, tgt.ID, "v1", AutoLun, false)
+	if !errors.As(err, &conflictErr) || conflictErr.Lun != 1 || conflictErr.VolID != "v1" {
+		t.Fatalf("expected LunConflictError of volume v1, got %v", err)
+	}
+
+	// another client maps LUN 2 between ListLuns and the mapping request
+	lunsKey := http.MethodGet + " /rest/v2/dataTransfer/targets/" + tgt.ID + "/luns"
+	fake.mu.Lock()
+	listLuns := fake.handlers[lunsKey]
+	fake.mu.Unlock()
+	stale := true
+	fake.handle(http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgt.ID+"/luns", func(w http.ResponseWriter, r *http.Request) {
+		if stale {
+			stale = false
+			writeJSON(w, http.StatusOK, []LunData{})
+			return
+		}
+		listLuns(w, r)
+	})
+	_, err = targetOp.MapLun(ctx, tgt.ID, "v3", 2, false)
+	if !errors.As(err, &conflictErr) || conflictErr.Lun != 2 || conflictErr.VolID != "v2" {
+		t.Fatalf("expected LunConflictError of LUN 2 from the storage, got %v", err)
+	}
+	if n := fake.count(http.MethodPost, "/rest/v2/dataTransfer/targets/"+tgt.ID+"/luns"); n != 4 {
+		t.Fatalf("expected 4 mapping requests, got %d", n)
+	}
+	fake.handle(http.MethodGet, "/rest/v2/dataTransfer/targets/"+tgt.ID+"/luns", listLuns)
+
+	if _, err := targetOp.MapLun(ctx, tgt.ID, "v3", MaxLun+1, false); err == nil {
+		t.Fatalf("MapLun with an invalid LUN expected an error")
+	}
+
+	if err := targetOp.UnmapLun(ctx, tgt.ID, 1); err != nil {
+		t.Fatalf("UnmapLun failed: %v", err)
+	}
+	luns, err := targetOp.ListLuns(ctx, tgt.ID)
+	if err != nil {
+		t.Fatalf("ListLuns failed: %v", err)
+	}
+	if len(*luns) != 2 {
+		t.Fatalf("unexpected LUNs: %+v", *luns)
+	}
+
+	lun, err := targetOp.MapLun(ctx, tgt.ID, "v3", AutoLun, false)
+	if err != nil {
+		t.Fatalf("MapLun failed: %v", err)
+	}
+	if lun.Lun != 1 {
+		t.Fatalf("expected the freed LUN 1, got %d", lun.Lun)
+	}
+
+	res, err := targetOp.GetTarget(ctx, tgt.ID)
+	if err != nil {
+		t.Fatalf("GetTarget failed: %v", err)
+	}
+	if len(res.Luns) != 3 {
+		t.Fatalf("unexpected target LUNs: %+v", res.Luns)
+	}
+}
+
+func TestChapSettings(t *testing.T) {
+	valids := []*ChapSettings{
+		nil,
+		{},
+		{Username: "user", Secret: "secret-123456"},
+		{Username: "user", Secret: "secret-123456", MutualUsername: "target", MutualSecret: "secret-654321"},
+	}
+	for _, c := range valids {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validate(%+v) failed: %v", c, err)
+		}
+	}
+
+	invalids := []*ChapSettings{
+		{Secret: "secret-123456"},
+		{Username: "user", Secret: "short"},
+		{Username: "user", Secret: "secret-12345678901"},
+		{Username: "user", Secret: "secret-123456", MutualSecret: "secret-654321"},
+		{Username: "user", Secret: "secret-123456", MutualUsername: "target", MutualSecret: "short"},
+		{Username: "user", Secret: "secret-123456", MutualUsername: "target", MutualSecret: "secret-123456"},
+	}
+	for _, c := range invalids {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) expected an error", c)
+		}
+	}
+
+	chap := ChapSettings{Username: "user", Secret: "secret-123456", MutualUsername: "target", MutualSecret: "secret-654321"}
+	param := CreateTargetParam{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}, Chap: &chap}}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%d"} {
+		if str := fmt.Sprintf(format, chap) + fmt.Sprintf(format, &chap) + fmt.Sprintf(format, param); strings.Contains(str, "secret-") {
+			t.Errorf("secret is printed with %s: %s", format, str)
+		}
+	}
+
+	rawdata, _ := json.Marshal(param)
+	if !strings.Contains(string(rawdata), `"secret":"secret-123456"`) || !strings.Contains(string(rawdata), `"mutualSecret":"secret-654321"`) {
+		t.Fatalf("secrets are not sent: %s", rawdata)
+	}
+	if body := redactBody(string(rawdata)); strings.Contains(body, "secret-") || !strings.Contains(body, `"username":"user"`) {
+		t.Fatalf("secrets are not redacted: %s", body)
+	}
+}
+
+func TestTargetChap(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeTargets(fake)
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	chap := &ChapSettings{Username: "user", Secret: "short"}
+	_, err := targetOp.CreateTarget(ctx, &CreateTargetParam{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}, Chap: chap}})
+	if err == nil {
+		t.Fatalf("CreateTarget with an invalid CHAP secret expected an error")
+	}
+	if n := fake.count(http.MethodPost, "/rest/v2/dataTransfer/targets"); n != 0 {
+		t.Fatalf("invalid CHAP settings should not be sent")
+	}
+
+	chap.Secret = "secret-123456"
+	tgt, err := targetOp.CreateTarget(ctx, &CreateTargetParam{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}, Chap: chap}})
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+
+	chap.MutualUsername = "target"
+	chap.MutualSecret = chap.Secret
+	if _, err := targetOp.UpdateTarget(ctx, tgt.ID, &UpdateTargetParam{Iscsi: &Iscsi{Chap: chap}}); err == nil {
+		t.Fatalf("UpdateTarget with the same mutual CHAP secret expected an error")
+	}
+	chap.MutualSecret = "secret-654321"
+	if _, err := targetOp.UpdateTarget(ctx, tgt.ID, &UpdateTargetParam{Iscsi: &Iscsi{Chap: chap}}); err != nil {
+		t.Fatalf("UpdateTarget failed: %v", err)
+	}
+	if _, err := targetOp.UpdateTarget(ctx, tgt.ID, &UpdateTargetParam{Iscsi: &Iscsi{Chap: &ChapSettings{}}}); err != nil {
+		t.Fatalf("UpdateTarget to disable CHAP failed: %v", err)
+	}
+}
+
+func TestFcTarget(t *testing.T) {
+	fake := newFakeServer(t)
+	ft := newFakeTargets(fake)
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	param := &CreateTargetParam{
+		Type: TargetTypeFC,
+		Fc:   &Fc{Ports: []string{"c0fc1", "c1fc1"}},
+		HostGroups: []HostGroup{
+			{Name: "esx", Hosts: []Host{NewHost("21:00:00:24:ff:4c:8f:4a"), NewHost("21000024ff4c8f4b")}},
+		},
+	}
+	tgt, err := targetOp.CreateTarget(ctx, param)
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+	if tgt.Type != TargetTypeFC || tgt.Fc == nil || !reflect.DeepEqual(tgt.Fc.Ports, param.Fc.Ports) {
+		t.Fatalf("unexpected FC target: %+v", *tgt)
+	}
+	ft.mu.Lock()
+	_, iscsi := ft.created["iscsi"]
+	ft.mu.Unlock()
+	if iscsi {
+		t.Fatalf("FC target request with iSCSI settings")
+	}
+
+	targetWwpns, hostWwpns := tgt.ZoningWwpns()
+	if len(targetWwpns) != 2 || !reflect.DeepEqual(hostWwpns, []string{"21:00:00:24:ff:4c:8f:4a", "21000024ff4c8f4b"}) {
+		t.Fatalf("unexpected zoning WWPNs: %v %v", targetWwpns, hostWwpns)
+	}
+
+	// replace the FC ports and the host WWPNs
+	updateParam := &UpdateTargetParam{
+		Fc:         &Fc{Ports: []string{"c0fc2", "c1fc2"}},
+		HostGroups: []HostGroup{{Name: "esx", Hosts: []Host{NewHost("21000024ff4c8f4c")}}},
+	}
+	if tgt, err = targetOp.UpdateTarget(ctx, tgt.ID, updateParam); err != nil {
+		t.Fatalf("UpdateTarget failed: %v", err)
+	}
+	targetWwpns, hostWwpns = tgt.ZoningWwpns()
+	if !reflect.DeepEqual(tgt.Fc.Ports, updateParam.Fc.Ports) || len(targetWwpns) != 2 || !reflect.DeepEqual(hostWwpns, []string{"21000024ff4c8f4c"}) {
+		t.Fatalf("unexpected FC target after update: %+v", *tgt)
+	}
+
+	invalidUpdates := []*UpdateTargetParam{
+		{Fc: &Fc{}},
+		{Fc: &Fc{Ports: []string{"c0fc1"}}, Iscsi: &Iscsi{Alias: "fc"}},
+		{Fc: &Fc{Ports: []string{"c0fc1"}}, HostGroups: []HostGroup{{Name: "g", Hosts: []Host{NewHost("iqn.1993-08.org.debian:01:node1")}}}},
+		{HostGroups: []HostGroup{{Name: "g", Hosts: []Host{NewHost("21000024ff4c8f")}}}},
+	}
+	expectInvalid(t, invalidUpdates, func(i int) error {
+		_, err := targetOp.UpdateTarget(ctx, tgt.ID, invalidUpdates[i])
+		return err
+	})
+	if n := fake.count(http.MethodPatch, "/rest/v2/dataTransfer/targets/"+tgt.ID); n != 1 {
+		t.Fatalf("expected 1 update request, got %d", n)
+	}
+
+	invalids := []*CreateTargetParam{
+		{Type: "SAS"},
+		{Type: TargetTypeFC},
+		{Type: TargetTypeFC, Fc: &Fc{Ports: []string{"c0fc1"}}, Iscsi: Iscsi{Eths: []string{"c0e1"}}},
+		{Type: TargetTypeFC, Fc: &Fc{Ports: []string{"c0fc1"}}, HostGroups: []HostGroup{{Name: "g", Hosts: []Host{NewHost("iqn.1993-08.org.debian:01:node1")}}}},
+		{Type: TargetTypeIscsi},
+		{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}}, Fc: &Fc{Ports: []string{"c0fc1"}}},
+		{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1"}}, HostGroups: []HostGroup{{Name: "g", Hosts: []Host{NewHost("node1")}}}},
+	}
+	for _, p := range invalids {
+		if _, err := targetOp.CreateTarget(ctx, p); err == nil {
+			t.Errorf("CreateTarget(%+v) expected an error", *p)
+		}
+	}
+	if n := fake.count(http.MethodPost, "/rest/v2/dataTransfer/targets"); n != 1 {
+		t.Fatalf("expected 1 create request, got %d", n)
+	}
+}
+
+func TestTargetPortals(t *testing.T) {
+	fake := newFakeServer(t)
+	newFakeTargets(fake)
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	tgt, err := targetOp.CreateTarget(ctx, &CreateTargetParam{Type: TargetTypeIscsi, Iscsi: Iscsi{Eths: []string{"c0e1", "c1e1"}, Alias: "k8s"}})
+	if err != nil {
+		t.Fatalf("CreateTarget failed: %v", err)
+	}
+	if tgt.Iscsi.Alias != "k8s" || tgt.Status != TargetOnline {
+		t.Fatalf("unexpected target: %+v", *tgt)
+	}
+
+	logins, err := tgt.IscsiLogins()
+	if err != nil {
+		t.Fatalf("IscsiLogins failed: %v", err)
+	}
+	want := []IscsiLogin{
+		{Portal: "192.168.10.1:3260", Iqn: tgt.Iscsi.Iqn},
+		{Portal: "192.168.10.2:3260", Iqn: tgt.Iscsi.Iqn},
+	}
+	if !reflect.DeepEqual(logins, want) {
+		t.Fatalf("unexpected logins: %+v", logins)
+	}
+
+	if addr := (Portal{IP: "fd00::10"}).Address(); addr != "[fd00::10]:3260" {
+		t.Fatalf("unexpected IPv6 portal address: %s", addr)
+	}
+
+	data := []byte(`{"id":"tgt9","type":"iSCSI","status":"degraded","sessionCount":2,"iscsi":{"iqn":"iqn.2004-08.com.qsan:tgt9","alias":null,"eths":["c0e1"],"portals":[{"eth":"c0e1","ip":"10.0.0.1","port":3261}]}}`)
+	res := TargetData{}
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	logins, err = res.IscsiLogins()
+	if err != nil || res.Status != TargetDegraded || res.SessionCount != 2 || logins[0].Portal != "10.0.0.1:3261" {
+		t.Fatalf("unexpected target: %+v, %v", res, err)
+	}
+
+	fc := TargetData{ID: "tgt10", Type: TargetTypeFC}
+	if _, err := fc.IscsiLogins(); err == nil {
+		t.Fatalf("IscsiLogins of a FC target expected an error")
+	}
+}
+
+func TestTargetSessions(t *testing.T) {
+	fake := newFakeServer(t)
+	loginTime := time.Date(2022, 5, 1, 8, 30, 0, 0, time.UTC)
+	sessions := []SessionData{
+		{ID: "s1", InitiatorIqn: "iqn.1993-08.org.debian:01:node1", SourceIP: "192.168.10.101", Connections: 2, LoginTime: loginTime},
+		{ID: "s2", InitiatorIqn: "iqn.1993-08.org.debian:01:node2", SourceIP: "192.168.10.102", Connections: 1, LoginTime: loginTime},
+	}
+	fake.handle(http.MethodGet, "/rest/v2/dataTransfer/targets/tgt1/sessions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, sessions)
+	})
+	fake.handle(http.MethodDelete, "/rest/v2/dataTransfer/targets/tgt1/sessions/s1", func(w http.ResponseWriter, r *http.Request) {
+		sessions = sessions[1:]
+		writeJSON(w, http.StatusOK, EmptyData{})
+	})
+	targetOp := NewTarget(fake.authClient(t))
+	ctx := context.Background()
+
+	res, err := targetOp.ListSessions(ctx, "tgt1")
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(*res) != 2 || (*res)[0].Connections != 2 || !(*res)[0].LoginTime.Equal(loginTime) {
+		t.Fatalf("unexpected sessions: %+v", *res)
+	}
+
+	if err := targetOp.DisconnectSession(ctx, "tgt1", "s1"); err != nil {
+		t.Fatalf("DisconnectSession failed: %v", err)
+	}
+	res, err = targetOp.ListSessions(ctx, "tgt1")
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(*res) != 1 || (*res)[0].ID != "s2" {
+		t.Fatalf("unexpected sessions: %+v", *res)
+	}
+
+	if err := targetOp.DisconnectSession(ctx, "tgt1", "s9"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("DisconnectSession of an unknown session expected ErrNotFound, got %v", err)
+	}
 }
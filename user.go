@@ -0,0 +1,264 @@
+// @2022 QSAN Inc. All rights reserved
+
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// UserOp handles local user account related methods of the QSM storage.
+// The methods return an error matching ErrPermissionDenied when the role of the client user is not allowed.
+type UserOp struct {
+	client *AuthClient
+}
+
+// Role is the role of a user account.
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"    // full access, including user management
+	RoleOperator Role = "operator" // manages volumes and targets, ex a service account of a cluster
+	RoleMonitor  Role = "monitor"  // read only access
+)
+
+// Validate checks whether the role is known.
+func (r Role) Validate() error {
+	switch r {
+	case RoleAdmin, RoleOperator, RoleMonitor:
+		return nil
+	}
+	return fmt.Errorf("invalid role %q", r)
+}
+
+// The response data of user related methods, ex ListUsers and CreateUser.
+type UserData struct {
+	Name        string    `json:"name"`
+	Role        Role      `json:"role"`
+	Description string    `json:"description"`
+	Builtin     bool      `json:"builtin"` // the built-in admin account cannot be deleted
+	LastLogin   time.Time `json:"lastLogin"`
+}
+
+// Password is the password of a user account, it is masked when printed with the fmt package.
+type Password string
+
+func (p Password) String() string {
+	return "******"
+}
+
+// Format masks the password for every verb of the fmt package.
+func (p Password) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		io.WriteString(f, `"******"`)
+		return
+	}
+	io.WriteString(f, p.String())
+}
+
+// UserParam is the parameter of CreateUser.
+type UserParam struct {
+	Name        string   `json:"name"`
+	Password    Password `json:"password"`
+	Role        Role     `json:"role"`
+	Description string   `json:"description,omitempty"`
+}
+
+// UpdateUserParam is the parameter of UpdateUser. Nil fields are not changed.
+type UpdateUserParam struct {
+	Role        *Role   `json:"role,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+const minPasswordLen = 8
+
+var userNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,31}$`)
+
+func validateUserName(name string) error {
+	if !userNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid user name %q", name)
+	}
+	return nil
+}
+
+func validatePassword(password Password) error {
+	if len(password) < minPasswordLen {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLen)
+	}
+	return nil
+}
+
+// Validate checks the user name, the password and the role.
+func (p *UserParam) Validate() error {
+	if err := validateUserName(p.Name); err != nil {
+		return err
+	}
+	if err := validatePassword(p.Password); err != nil {
+		return err
+	}
+	return p.Role.Validate()
+}
+
+func userPath(name string) string {
+	return "/rest/v2/system/users/" + name
+}
+
+// NewUser returns user operation
+func NewUser(client *AuthClient) *UserOp {
+	return &UserOp{client}
+}
+
+// ListUsers list all local users
+func (u *UserOp) ListUsers(ctx context.Context) (*[]UserData, error) {
+	req, err := u.client.NewRequest(ctx, http.MethodGet, "/rest/v2/system/users", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []UserData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetUser get a local user by name
+func (u *UserOp) GetUser(ctx context.Context, name string) (*UserData, error) {
+	if err := validateUserName(name); err != nil {
+		return nil, err
+	}
+
+	req, err := u.client.NewRequest(ctx, http.MethodGet, userPath(name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CreateUser create a local user
+func (u *UserOp) CreateUser(ctx context.Context, param *UserParam) (*UserData, error) {
+	if err := param.Validate(); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(param)
+	req, err := u.client.NewRequest(ctx, http.MethodPost, "/rest/v2/system/users", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UpdateUser update the role or description of a local user
+func (u *UserOp) UpdateUser(ctx context.Context, name string, param *UpdateUserParam) (*UserData, error) {
+	if err := validateUserName(name); err != nil {
+		return nil, err
+	}
+	if param.Role != nil {
+		if err := param.Role.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	rawdata, _ := json.Marshal(param)
+	req, err := u.client.NewRequest(ctx, http.MethodPatch, userPath(name), string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := UserData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// SetRole assign a role to a local user
+func (u *UserOp) SetRole(ctx context.Context, name string, role Role) (*UserData, error) {
+	return u.UpdateUser(ctx, name, &UpdateUserParam{Role: &role})
+}
+
+// DeleteUser delete a local user
+func (u *UserOp) DeleteUser(ctx context.Context, name string) error {
+	if err := validateUserName(name); err != nil {
+		return err
+	}
+
+	req, err := u.client.NewRequest(ctx, http.MethodDelete, userPath(name), nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ChangePassword change the password of a local user. An admin may leave oldPassword empty to reset
+// the password of another user.
+func (u *UserOp) ChangePassword(ctx context.Context, name, oldPassword, newPassword string) error {
+	if err := validateUserName(name); err != nil {
+		return err
+	}
+	if err := validatePassword(Password(newPassword)); err != nil {
+		return err
+	}
+
+	param := struct {
+		OldPassword Password `json:"oldPassword,omitempty"`
+		NewPassword Password `json:"newPassword"`
+	}{Password(oldPassword), Password(newPassword)}
+	rawdata, _ := json.Marshal(param)
+	req, err := u.client.NewRequest(ctx, http.MethodPut, userPath(name)+"/password", string(rawdata))
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ExpireSessions log out all sessions of a local user, ex after its password was changed
+func (u *UserOp) ExpireSessions(ctx context.Context, name string) error {
+	if err := validateUserName(name); err != nil {
+		return err
+	}
+
+	req, err := u.client.NewRequest(ctx, http.MethodDelete, userPath(name)+"/sessions", nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := u.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
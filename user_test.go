@@ -0,0 +1,254 @@
+package goqsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeUsers serves the user API on a fake server. Only a caller with RoleAdmin may change other users.
+type fakeUsers struct {
+	fake      *fakeServer
+	mu        sync.Mutex
+	caller    Role
+	users     map[string]*UserData
+	passwords map[string]string
+	sessions  map[string]int
+}
+
+func newFakeUsers(fake *fakeServer) *fakeUsers {
+	fu := &fakeUsers{
+		fake:      fake,
+		caller:    RoleAdmin,
+		users:     map[string]*UserData{},
+		passwords: map[string]string{},
+		sessions:  map[string]int{},
+	}
+	fu.users["admin"] = &UserData{Name: "admin", Role: RoleAdmin, Builtin: true}
+	fu.passwords["admin"] = "1234"
+	fu.handleUser("admin")
+
+	fake.handle(http.MethodGet, "/rest/v2/system/users", func(w http.ResponseWriter, r *http.Request) {
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		users := []UserData{}
+		for _, u := range fu.users {
+			users = append(users, *u)
+		}
+		writeJSON(w, http.StatusOK, users)
+	})
+	fake.handle(http.MethodPost, "/rest/v2/system/users", func(w http.ResponseWriter, r *http.Request) {
+		param := UserParam{}
+		json.NewDecoder(r.Body).Decode(&param)
+
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		if !fu.allowed(w) {
+			return
+		}
+		if _, ok := fu.users[param.Name]; ok {
+			writeError(w, http.StatusConflict, "user exists")
+			return
+		}
+		fu.users[param.Name] = &UserData{Name: param.Name, Role: param.Role, Description: param.Description}
+		fu.passwords[param.Name] = string(param.Password)
+		fu.sessions[param.Name] = 1
+		fu.handleUser(param.Name)
+		writeJSON(w, http.StatusOK, fu.users[param.Name])
+	})
+
+	return fu
+}
+
+func (fu *fakeUsers) allowed(w http.ResponseWriter) bool {
+	if fu.caller != RoleAdmin {
+		writeError(w, http.StatusForbidden, "permission denied")
+		return false
+	}
+	return true
+}
+
+func (fu *fakeUsers) handleUser(name string) {
+	path := "/rest/v2/system/users/" + name
+	fu.fake.handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		u, ok := fu.users[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, u)
+	})
+	fu.fake.handle(http.MethodPatch, path, func(w http.ResponseWriter, r *http.Request) {
+		param := UpdateUserParam{}
+		json.NewDecoder(r.Body).Decode(&param)
+
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		if !fu.allowed(w) {
+			return
+		}
+		u := fu.users[name]
+		if param.Role != nil {
+			u.Role = *param.Role
+		}
+		if param.Description != nil {
+			u.Description = *param.Description
+		}
+		writeJSON(w, http.StatusOK, u)
+	})
+	fu.fake.handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request) {
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		if !fu.allowed(w) {
+			return
+		}
+		if fu.users[name].Builtin {
+			writeError(w, http.StatusForbidden, "built-in user cannot be deleted")
+			return
+		}
+		delete(fu.users, name)
+		writeJSON(w, http.StatusOK, EmptyData{})
+	})
+	fu.fake.handle(http.MethodPut, path+"/password", func(w http.ResponseWriter, r *http.Request) {
+		param := struct {
+			OldPassword string `json:"oldPassword"`
+			NewPassword string `json:"newPassword"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&param)
+
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		if param.OldPassword == "" && !fu.allowed(w) {
+			return
+		}
+		if param.OldPassword != "" && param.OldPassword != fu.passwords[name] {
+			writeError(w, http.StatusBadRequest, "wrong password")
+			return
+		}
+		fu.passwords[name] = param.NewPassword
+		writeJSON(w, http.StatusOK, EmptyData{})
+	})
+	fu.fake.handle(http.MethodDelete, path+"/sessions", func(w http.ResponseWriter, r *http.Request) {
+		fu.mu.Lock()
+		defer fu.mu.Unlock()
+		if !fu.allowed(w) {
+			return
+		}
+		fu.sessions[name] = 0
+		writeJSON(w, http.StatusOK, EmptyData{})
+	})
+}
+
+func TestUserLifecycle(t *testing.T) {
+	fake := newFakeServer(t)
+	fu := newFakeUsers(fake)
+	userOp := NewUser(fake.authClient(t))
+	ctx := context.Background()
+
+	user, err := userOp.CreateUser(ctx, &UserParam{Name: "k8s-cluster1", Password: "s3cret-pass", Role: RoleOperator, Description: "CSI driver"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if user.Role != RoleOperator || user.Description != "CSI driver" {
+		t.Fatalf("unexpected user: %+v", *user)
+	}
+
+	users, err := userOp.ListUsers(ctx)
+	if err != nil || len(*users) != 2 {
+		t.Fatalf("ListUsers failed: %v, %+v", err, users)
+	}
+
+	if user, err = userOp.SetRole(ctx, "k8s-cluster1", RoleMonitor); err != nil || user.Role != RoleMonitor || user.Description != "CSI driver" {
+		t.Fatalf("SetRole failed: %v, %+v", err, user)
+	}
+
+	if err := userOp.ChangePassword(ctx, "k8s-cluster1", "s3cret-pass", "n3w-s3cret"); err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if err := userOp.ChangePassword(ctx, "k8s-cluster1", "s3cret-pass", "an0ther-s3cret"); err == nil {
+		t.Fatalf("expected an error for a wrong old password")
+	}
+	if err := userOp.ExpireSessions(ctx, "k8s-cluster1"); err != nil {
+		t.Fatalf("ExpireSessions failed: %v", err)
+	}
+	fu.mu.Lock()
+	sessions := fu.sessions["k8s-cluster1"]
+	fu.mu.Unlock()
+	if sessions != 0 {
+		t.Fatalf("%d sessions left after ExpireSessions", sessions)
+	}
+
+	if err := userOp.DeleteUser(ctx, "k8s-cluster1"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if _, err := userOp.GetUser(ctx, "k8s-cluster1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUserPermissionDenied(t *testing.T) {
+	fake := newFakeServer(t)
+	fu := newFakeUsers(fake)
+	userOp := NewUser(fake.authClient(t))
+	ctx := context.Background()
+
+	if err := userOp.DeleteUser(ctx, "admin"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied deleting the built-in admin, got %v", err)
+	}
+
+	fu.mu.Lock()
+	fu.caller = RoleOperator
+	fu.mu.Unlock()
+
+	if _, err := userOp.CreateUser(ctx, &UserParam{Name: "backup", Password: "s3cret-pass", Role: RoleAdmin}); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := userOp.SetRole(ctx, "admin", RoleMonitor); !errors.Is(err, ErrPermissionDenied) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+	if err := userOp.ChangePassword(ctx, "admin", "", "n3w-s3cret"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+	if err := userOp.ExpireSessions(ctx, "admin"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected ErrPermissionDenied, got %v", err)
+	}
+	// reading users is allowed
+	if _, err := userOp.GetUser(ctx, "admin"); err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+}
+
+func TestUserParamValidate(t *testing.T) {
+	invalid := []UserParam{
+		{Name: "", Password: "s3cret-pass", Role: RoleOperator},
+		{Name: "1user", Password: "s3cret-pass", Role: RoleOperator},
+		{Name: "user/x", Password: "s3cret-pass", Role: RoleOperator},
+		{Name: "user", Password: "short", Role: RoleOperator},
+		{Name: "user", Password: "s3cret-pass", Role: "root"},
+	}
+	expectInvalid(t, invalid, func(i int) error {
+		return invalid[i].Validate()
+	})
+
+	valid := UserParam{Name: "k8s-cluster1.csi_svc", Password: "s3cret-pass", Role: RoleMonitor}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x"} {
+		if s := fmt.Sprintf(format, valid); strings.Contains(s, "s3cret") {
+			t.Errorf("password is not masked with %s: %s", format, s)
+		}
+	}
+	rawdata, _ := json.Marshal(valid)
+	if !strings.Contains(string(rawdata), `"password":"s3cret-pass"`) {
+		t.Errorf("password is not sent: %s", rawdata)
+	}
+}
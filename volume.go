@@ -5,9 +5,13 @@ package goqsm
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 // VolumeOp handles volume related methods of the QSM storage.
@@ -24,13 +28,299 @@ type VolumeData struct {
 	VMPath  string `json:"vmPath"`
 	SizeMB  uint64 `json:"sizeMB"`
 	UsedMB  uint64 `json:"usedMB"`
+
+	// The capacity efficiency of the volume, zero when the storage does not report it
+	LogicalUsedMB   uint64 `json:"logicalUsedMB,omitempty"`   // data written by the hosts
+	PhysicalUsedMB  uint64 `json:"physicalUsedMB,omitempty"`  // space used by the data after compression and dedup
+	CompressSavedMB uint64 `json:"compressSavedMB,omitempty"` // space saved by compression
+	DedupSavedMB    uint64 `json:"dedupSavedMB,omitempty"`    // space saved by dedup
+	SnapshotMB      uint64 `json:"snapshotMB,omitempty"`      // space used by the snapshots of the volume
 }
 
+// Provision is the provisioning type of a volume.
+type Provision string
+
+const (
+	ProvisionThin  Provision = "thin"
+	ProvisionThick Provision = "thick"
+)
+
+// Compression is the compression algorithm of a volume.
+type Compression string
+
+const (
+	CompressionOn          Compression = "on"
+	CompressionOff         Compression = "off"
+	CompressionGenericZero Compression = "genericzero"
+	CompressionEmpty       Compression = "empty"
+	CompressionLz4         Compression = "lz4"
+)
+
+const (
+	minVolumeBlockSize = 1024
+	maxVolumeBlockSize = 65536
+)
+
+// VolumeCreateOptions are optional settings of CreateVolume. Zero values keep the storage defaults.
 type VolumeCreateOptions struct {
-	BlockSize uint   // recordsize: 1024, 2048 ..., 65536
-	Provision string // "thin" or "thick"
-	Compress  string // "on", "off", "genericzero", "empty" or "lz4"
-	Dedup     bool   // true: enable dedup, otherwise disable
+	BlockSize uint        `json:"blockSize,omitempty"` // recordsize: 1024, 2048 ..., 65536
+	Provision Provision   `json:"provision,omitempty"` // ProvisionThin or ProvisionThick
+	Compress  Compression `json:"compress,omitempty"`  // CompressionOn, CompressionOff, CompressionGenericZero, CompressionEmpty or CompressionLz4
+	Dedup     bool        `json:"dedup,omitempty"`     // true: enable dedup, otherwise disable
+}
+
+// Validate checks the options before they are sent to the storage. A nil options is valid.
+func (o *VolumeCreateOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.BlockSize != 0 {
+		if o.BlockSize < minVolumeBlockSize || o.BlockSize > maxVolumeBlockSize || o.BlockSize&(o.BlockSize-1) != 0 {
+			return fmt.Errorf("invalid block size %d: must be a power of two between %d and %d", o.BlockSize, minVolumeBlockSize, maxVolumeBlockSize)
+		}
+	}
+
+	switch o.Provision {
+	case "", ProvisionThin, ProvisionThick:
+	default:
+		return fmt.Errorf("invalid provision %q", o.Provision)
+	}
+
+	switch o.Compress {
+	case "", CompressionOn, CompressionOff, CompressionGenericZero, CompressionEmpty, CompressionLz4:
+	default:
+		return fmt.Errorf("invalid compression %q", o.Compress)
+	}
+
+	return nil
+}
+
+// addParams adds the non-empty options to the form parameters of a request.
+func (o *VolumeCreateOptions) addParams(params url.Values) {
+	if o == nil {
+		return
+	}
+
+	if o.BlockSize != 0 {
+		params.Add("blockSize", strconv.FormatUint(uint64(o.BlockSize), 10))
+	}
+	if o.Provision != "" {
+		params.Add("provision", string(o.Provision))
+	}
+	if o.Compress != "" {
+		params.Add("compress", string(o.Compress))
+	}
+	if o.Dedup {
+		params.Add("dedup", "on")
+	}
+}
+
+// NfsSquash is the user mapping of a NFS export.
+type NfsSquash string
+
+const (
+	NfsRootSquash   NfsSquash = "root_squash"    // map root to the anonymous user
+	NfsNoRootSquash NfsSquash = "no_root_squash" // keep root as root
+	NfsAllSquash    NfsSquash = "all_squash"     // map every user to the anonymous user
+)
+
+// NfsVersion is a NFS protocol version of an export.
+type NfsVersion string
+
+const (
+	NfsV3  NfsVersion = "3"
+	NfsV4  NfsVersion = "4"
+	NfsV41 NfsVersion = "4.1"
+)
+
+// ExportAccess is the access of the clients of a NFS export.
+type ExportAccess string
+
+const (
+	ExportAccessDefault ExportAccess = ""   // the storage default access
+	ExportReadOnly      ExportAccess = "ro" // read-only
+	ExportReadWrite     ExportAccess = "rw" // read-write
+)
+
+// ExportOptions are optional settings of ExportVolume. Zero values keep the storage defaults.
+type ExportOptions struct {
+	Clients  []string     // allowed client hosts, IPs or CIDRs, ex "10.0.0.5", "10.0.0.0/24", "*.example.com". Empty: all clients
+	Access   ExportAccess // ExportReadOnly or ExportReadWrite. Empty: the storage default access
+	Squash   NfsSquash    // NfsRootSquash, NfsNoRootSquash or NfsAllSquash
+	Versions []NfsVersion // allowed NFS versions. Empty: all versions
+}
+
+// The response data of GetExport method
+type ExportData struct {
+	Clients  []string     `json:"clients"`
+	ReadOnly bool         `json:"readOnly"`
+	Squash   NfsSquash    `json:"squash"`
+	Versions []NfsVersion `json:"nfsVersions"`
+
+	// MountPath is the export path clients should mount, the VMPath of the volume.
+	MountPath string `json:"-"`
+}
+
+var nfsHostnameRegexp = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+// Validate checks the options before they are sent to the storage. A nil options is valid.
+func (o *ExportOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	for _, c := range o.Clients {
+		if c == "*" || net.ParseIP(c) != nil || nfsHostnameRegexp.MatchString(c) {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(c); err == nil {
+			continue
+		}
+		return fmt.Errorf("invalid NFS client %q", c)
+	}
+
+	switch o.Access {
+	case ExportAccessDefault, ExportReadOnly, ExportReadWrite:
+	default:
+		return fmt.Errorf("invalid NFS access %q", o.Access)
+	}
+
+	switch o.Squash {
+	case "", NfsRootSquash, NfsNoRootSquash, NfsAllSquash:
+	default:
+		return fmt.Errorf("invalid NFS squash %q", o.Squash)
+	}
+
+	for _, ver := range o.Versions {
+		switch ver {
+		case NfsV3, NfsV4, NfsV41:
+		default:
+			return fmt.Errorf("invalid NFS version %q", ver)
+		}
+	}
+
+	return nil
+}
+
+// MountSource returns the mount source of the export on the given NFS server address, ex "192.168.1.10:/share/vol1".
+func (d *ExportData) MountSource(server string) string {
+	return server + ":" + d.MountPath
+}
+
+// SmbPrincipalType is the type of an account in a SMB share ACL.
+type SmbPrincipalType string
+
+const (
+	SmbUser  SmbPrincipalType = "user"
+	SmbGroup SmbPrincipalType = "group"
+)
+
+// SmbPermission is the access right of an account in a SMB share ACL.
+type SmbPermission string
+
+const (
+	SmbReadOnly    SmbPermission = "read"
+	SmbReadWrite   SmbPermission = "change"
+	SmbFullControl SmbPermission = "full"
+	SmbDeny        SmbPermission = "deny"
+)
+
+// SmbProtocol is the minimum SMB protocol version a share accepts.
+type SmbProtocol string
+
+const (
+	SmbProtocol2  SmbProtocol = "SMB2"
+	SmbProtocol21 SmbProtocol = "SMB2.1"
+	SmbProtocol3  SmbProtocol = "SMB3"
+)
+
+// SmbAce is an access control entry of a SMB share.
+type SmbAce struct {
+	Type       SmbPrincipalType `json:"type"`
+	Name       string           `json:"name"`
+	Permission SmbPermission    `json:"permission"`
+}
+
+// SmbShareOptions are settings of CreateSmbShare and UpdateSmbShare.
+type SmbShareOptions struct {
+	Name        string      `json:"name"`               // share name, up to 80 characters
+	ACL         []SmbAce    `json:"acl,omitempty"`      // access control list. Empty: the storage default
+	GuestAccess bool        `json:"guestAccess"`        // true: allow guest access without authentication
+	Protocol    SmbProtocol `json:"protocol,omitempty"` // SmbProtocol2, SmbProtocol21 or SmbProtocol3
+}
+
+// The response data of SMB share related methods, ex CreateSmbShare and GetSmbShare.
+type SmbShareData struct {
+	Name        string      `json:"name"`
+	ACL         []SmbAce    `json:"acl"`
+	GuestAccess bool        `json:"guestAccess"`
+	Protocol    SmbProtocol `json:"protocol"`
+	Path        string      `json:"path"` // UNC path, ex \\server\share
+}
+
+const smbShareNameMaxLen = 80
+
+// Validate checks the options before they are sent to the storage.
+func (o *SmbShareOptions) Validate() error {
+	if o == nil {
+		return fmt.Errorf("SMB share options are required")
+	}
+
+	if o.Name == "" || len(o.Name) > smbShareNameMaxLen || strings.ContainsAny(o.Name, `\/:*?"<>|[]+=;,`) {
+		return fmt.Errorf("invalid SMB share name %q", o.Name)
+	}
+
+	for _, ace := range o.ACL {
+		if ace.Name == "" {
+			return fmt.Errorf("SMB ACL entry without name")
+		}
+		switch ace.Type {
+		case SmbUser, SmbGroup:
+		default:
+			return fmt.Errorf("invalid SMB ACL type %q of %s", ace.Type, ace.Name)
+		}
+		switch ace.Permission {
+		case SmbReadOnly, SmbReadWrite, SmbFullControl, SmbDeny:
+		default:
+			return fmt.Errorf("invalid SMB ACL permission %q of %s", ace.Permission, ace.Name)
+		}
+	}
+
+	switch o.Protocol {
+	case "", SmbProtocol2, SmbProtocol21, SmbProtocol3:
+	default:
+		return fmt.Errorf("invalid SMB protocol %q", o.Protocol)
+	}
+
+	return nil
+}
+
+// VolumeResizeOptions are optional settings of ResizeVolume.
+type VolumeResizeOptions struct {
+	AllowShrink bool // true: allow a size smaller than the current one, data beyond the new size is lost
+}
+
+// VolumeShrinkError is returned by ResizeVolume when the new size is smaller than the current size
+// and shrink is not allowed.
+type VolumeShrinkError struct {
+	VolID       string
+	CurrentMB   uint64
+	RequestedMB uint64
+}
+
+func (e *VolumeShrinkError) Error() string {
+	return fmt.Sprintf("refuse to shrink volume %s from %d MB to %d MB", e.VolID, e.CurrentMB, e.RequestedMB)
+}
+
+// Size returns the provisioned size of the volume.
+func (d *VolumeData) Size() Capacity {
+	return CapacityFromMB(d.SizeMB)
+}
+
+// Used returns the used size of the volume.
+func (d *VolumeData) Used() Capacity {
+	return CapacityFromMB(d.UsedMB)
 }
 
 // NewVolume returns volume operation
@@ -56,27 +346,15 @@ func (v *VolumeOp) ListVolumes(ctx context.Context, scId, volId string) (*[]Volu
 
 // CreateVolume create a volume on a storage container
 func (v *VolumeOp) CreateVolume(ctx context.Context, scId, name string, size uint64, options *VolumeCreateOptions) (*VolumeData, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
 	params := url.Values{}
 	params.Add("name", name)
 	params.Add("sizeMB", strconv.FormatUint(size, 10))
 
-	var optionMap map[string]interface{}
-	data, _ := json.Marshal(options)
-	json.Unmarshal(data, &optionMap)
-	// 'int' type will become 'float64' type after struct to map[string]interface{} conversion
-	if optionMap["BlockSize"] != float64(0) {
-		v := uint64(optionMap["BlockSize"].(float64))
-		params.Add("blockSize", strconv.FormatUint(v, 10))
-	}
-	if optionMap["Provision"] != "" {
-		params.Add("provision", optionMap["Provision"].(string))
-	}
-	if optionMap["Compress"] != "" {
-		params.Add("compress", optionMap["Compress"].(string))
-	}
-	if optionMap["Dedup"] == true {
-		params.Add("dedup", "on")
-	}
+	options.addParams(params)
 
 	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/internal/cloud/containers/"+scId+"/vols", params)
 	if err != nil {
@@ -91,6 +369,15 @@ func (v *VolumeOp) CreateVolume(ctx context.Context, scId, name string, size uin
 	return &res, nil
 }
 
+// CreateVolumeWithCapacity create a volume like CreateVolume, the size is rounded up to the allocation granularity of the storage
+func (v *VolumeOp) CreateVolumeWithCapacity(ctx context.Context, scId, name string, size Capacity, options *VolumeCreateOptions) (*VolumeData, error) {
+	if size == 0 {
+		return nil, fmt.Errorf("invalid volume size %s", size)
+	}
+
+	return v.CreateVolume(ctx, scId, name, size.MB(), options)
+}
+
 // DeleteVolume delete a volume from a storage container
 func (v *VolumeOp) DeleteVolume(ctx context.Context, scId, volId string) error {
 	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId, nil)
@@ -106,8 +393,34 @@ func (v *VolumeOp) DeleteVolume(ctx context.Context, scId, volId string) error {
 	return nil
 }
 
-// ResizeVolume resize or expand a volume from a storage container
+// ResizeVolume resize or expand a volume from a storage container.
+// A shrink is refused with VolumeShrinkError, and resizing to the current size is a no-op.
 func (v *VolumeOp) ResizeVolume(ctx context.Context, scId, volId string, size uint64) error {
+	return v.ResizeVolumeWithOptions(ctx, scId, volId, size, nil)
+}
+
+// ResizeVolumeWithOptions resize a volume like ResizeVolume, a shrink is allowed if options.AllowShrink is set.
+func (v *VolumeOp) ResizeVolumeWithOptions(ctx context.Context, scId, volId string, size uint64, options *VolumeResizeOptions) error {
+	if volId == "" {
+		return fmt.Errorf("volume id is required")
+	}
+
+	vols, err := v.ListVolumes(ctx, scId, volId)
+	if err != nil {
+		return err
+	}
+	if len(*vols) != 1 {
+		return fmt.Errorf("volume %s not found", volId)
+	}
+
+	curSize := (*vols)[0].SizeMB
+	if size == curSize {
+		return nil
+	}
+	if size < curSize && (options == nil || !options.AllowShrink) {
+		return &VolumeShrinkError{VolID: volId, CurrentMB: curSize, RequestedMB: size}
+	}
+
 	params := url.Values{}
 	params.Add("sizeMB", strconv.FormatUint(size, 10))
 
@@ -124,9 +437,42 @@ func (v *VolumeOp) ResizeVolume(ctx context.Context, scId, volId string, size ui
 	return nil
 }
 
-// ExportVolume export a NFS volume
-func (v *VolumeOp) ExportVolume(ctx context.Context, scId, volId string) error {
-	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/share", nil)
+// ResizeVolumeWithCapacity resize a volume like ResizeVolume, the size is rounded up to the allocation granularity of the storage
+func (v *VolumeOp) ResizeVolumeWithCapacity(ctx context.Context, scId, volId string, size Capacity, options *VolumeResizeOptions) error {
+	if size == 0 {
+		return fmt.Errorf("invalid volume size %s", size)
+	}
+
+	return v.ResizeVolumeWithOptions(ctx, scId, volId, size.MB(), options)
+}
+
+// ExportVolume export a NFS volume, a nil options exports the volume with the storage defaults
+func (v *VolumeOp) ExportVolume(ctx context.Context, scId, volId string, options *ExportOptions) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	if options != nil {
+		if len(options.Clients) > 0 {
+			params.Add("clients", strings.Join(options.Clients, ","))
+		}
+		if options.Access != ExportAccessDefault {
+			params.Add("access", string(options.Access))
+		}
+		if options.Squash != "" {
+			params.Add("squash", string(options.Squash))
+		}
+		if len(options.Versions) > 0 {
+			vers := make([]string, len(options.Versions))
+			for i, ver := range options.Versions {
+				vers[i] = string(ver)
+			}
+			params.Add("nfsVersions", strings.Join(vers, ","))
+		}
+	}
+
+	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/share", params)
 	if err != nil {
 		return err
 	}
@@ -139,6 +485,30 @@ func (v *VolumeOp) ExportVolume(ctx context.Context, scId, volId string) error {
 	return nil
 }
 
+// GetExport get the NFS export settings and the mount path of a volume
+func (v *VolumeOp) GetExport(ctx context.Context, scId, volId string) (*ExportData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/share", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := ExportData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	vols, err := v.ListVolumes(ctx, scId, volId)
+	if err != nil {
+		return nil, err
+	}
+	if len(*vols) != 1 {
+		return nil, fmt.Errorf("volume %s not found", volId)
+	}
+	res.MountPath = (*vols)[0].VMPath
+
+	return &res, nil
+}
+
 // UnexportVolume unexport a NFS volume
 func (v *VolumeOp) UnexportVolume(ctx context.Context, scId, volId string) error {
 	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/share", nil)
@@ -153,3 +523,73 @@ func (v *VolumeOp) UnexportVolume(ctx context.Context, scId, volId string) error
 
 	return nil
 }
+
+// CreateSmbShare create a SMB share of a volume
+func (v *VolumeOp) CreateSmbShare(ctx context.Context, scId, volId string, options *SmbShareOptions) (*SmbShareData, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(options)
+	req, err := v.client.NewRequest(ctx, http.MethodPost, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/smb", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := SmbShareData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetSmbShare get the SMB share settings of a volume
+func (v *VolumeOp) GetSmbShare(ctx context.Context, scId, volId string) (*SmbShareData, error) {
+	req, err := v.client.NewRequest(ctx, http.MethodGet, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/smb", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := SmbShareData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// UpdateSmbShare update the SMB share settings of a volume
+func (v *VolumeOp) UpdateSmbShare(ctx context.Context, scId, volId string, options *SmbShareOptions) (*SmbShareData, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	rawdata, _ := json.Marshal(options)
+	req, err := v.client.NewRequest(ctx, http.MethodPatch, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/smb", string(rawdata))
+	if err != nil {
+		return nil, err
+	}
+
+	res := SmbShareData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// DeleteSmbShare delete the SMB share of a volume
+func (v *VolumeOp) DeleteSmbShare(ctx context.Context, scId, volId string) error {
+	req, err := v.client.NewRequest(ctx, http.MethodDelete, "/rest/internal/cloud/containers/"+scId+"/vols/"+volId+"/smb", nil)
+	if err != nil {
+		return err
+	}
+
+	res := EmptyData{}
+	if err := v.client.SendRequest(ctx, req, &res); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,13 +2,20 @@ package goqsm
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestVolume(t *testing.T) {
 	fmt.Println("------------TestVolume--------------")
+	skipIfNoTestConf(t)
 	ctx = context.Background()
 
 	options1 := VolumeCreateOptions{
@@ -30,6 +37,8 @@ func TestVolume(t *testing.T) {
 	resizeVolumeTest(t)
 
 	exportUnexportVolumeTest(t)
+
+	smbShareTest(t)
 }
 
 func createDeleteVolumeTest(t *testing.T, volSize uint64, options *VolumeCreateOptions) {
@@ -136,12 +145,18 @@ func exportUnexportVolumeTest(t *testing.T) {
 	}
 	fmt.Printf("  A volume was created. Id:%s, path: %s \n", vol.ID, vol.VMPath)
 
-	err = testConf.volumeOp.ExportVolume(ctx, scId, vol.ID)
+	err = testConf.volumeOp.ExportVolume(ctx, scId, vol.ID, nil)
 	if err != nil {
 		t.Fatalf("ExportVolume failed: %v", err)
 	}
 	fmt.Printf("  A volume was exported. Id:%s\n", vol.ID)
 
+	export, err := testConf.volumeOp.GetExport(ctx, scId, vol.ID)
+	if err != nil {
+		t.Fatalf("GetExport failed: %v", err)
+	}
+	fmt.Printf("  Export settings: %+v\n", *export)
+
 	err = testConf.volumeOp.UnexportVolume(ctx, scId, vol.ID)
 	if err != nil {
 		t.Fatalf("UnexportVolume failed: %v", err)
@@ -156,3 +171,297 @@ func exportUnexportVolumeTest(t *testing.T) {
 
 	fmt.Println("exportUnexportVolumeTest Leave")
 }
+
+func smbShareTest(t *testing.T) {
+	fmt.Println("smbShareTest Enter")
+	now := time.Now()
+	timeStamp := now.Format("20060102150405")
+	volName := "gotest-vol-" + timeStamp
+	scId := testConf.scId
+	var volSize uint64 = 1024
+
+	options := VolumeCreateOptions{}
+	vol, err := testConf.volumeOp.CreateVolume(ctx, scId, volName, volSize, &options)
+	if err != nil {
+		t.Fatalf("createVolume failed: %v", err)
+	}
+	fmt.Printf("  A volume was created. Id:%s, path: %s \n", vol.ID, vol.VMPath)
+
+	shareOptions := SmbShareOptions{
+		Name:     "gotest-share-" + timeStamp,
+		ACL:      []SmbAce{{Type: SmbUser, Name: testConf.user, Permission: SmbFullControl}},
+		Protocol: SmbProtocol3,
+	}
+	share, err := testConf.volumeOp.CreateSmbShare(ctx, scId, vol.ID, &shareOptions)
+	if err != nil {
+		t.Fatalf("CreateSmbShare failed: %v", err)
+	}
+	fmt.Printf("  A SMB share was created. %+v\n", *share)
+
+	shareOptions.ACL[0].Permission = SmbReadOnly
+	_, err = testConf.volumeOp.UpdateSmbShare(ctx, scId, vol.ID, &shareOptions)
+	if err != nil {
+		t.Fatalf("UpdateSmbShare failed: %v", err)
+	}
+	fmt.Printf("  A SMB share was updated. Name:%s\n", shareOptions.Name)
+
+	err = testConf.volumeOp.DeleteSmbShare(ctx, scId, vol.ID)
+	if err != nil {
+		t.Fatalf("DeleteSmbShare failed: %v", err)
+	}
+	fmt.Printf("  A SMB share was deleted. Name:%s\n", shareOptions.Name)
+
+	err = testConf.volumeOp.DeleteVolume(ctx, scId, vol.ID)
+	if err != nil {
+		t.Fatalf("DeleteVolume failed: %v", err)
+	}
+	fmt.Printf("  A volume was deleted. Id:%s\n", vol.ID)
+
+	fmt.Println("smbShareTest Leave")
+}
+
+func TestVolumeCreateOptionsValidate(t *testing.T) {
+	tests := []struct {
+		options *VolumeCreateOptions
+		valid   bool
+	}{
+		{nil, true},
+		{&VolumeCreateOptions{}, true},
+		{&VolumeCreateOptions{BlockSize: 1024, Provision: ProvisionThin, Compress: CompressionLz4, Dedup: true}, true},
+		{&VolumeCreateOptions{BlockSize: 65536, Provision: ProvisionThick, Compress: CompressionOff}, true},
+		{&VolumeCreateOptions{BlockSize: 3000}, false},
+		{&VolumeCreateOptions{BlockSize: 512}, false},
+		{&VolumeCreateOptions{BlockSize: 131072}, false},
+		{&VolumeCreateOptions{Provision: "fat"}, false},
+		{&VolumeCreateOptions{Compress: "zstd"}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.options.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%+v) failed: %v", tt.options, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Validate(%+v) expected an error", tt.options)
+		}
+	}
+}
+
+func TestCreateVolumeOptions(t *testing.T) {
+	fake := newFakeServer(t)
+	fake.handle(http.MethodPost, "/rest/internal/cloud/containers/sc1/vols", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("name") == "vol1" && (r.Form.Get("blockSize") != "4096" || r.Form.Get("provision") != "thin" || r.Form.Get("compress") != "lz4" || r.Form.Get("dedup") != "on") {
+			t.Errorf("unexpected form: %v", r.Form)
+		}
+		if r.Form.Get("name") == "vol2" && len(r.Form) != 2 {
+			t.Errorf("unexpected form: %v", r.Form)
+		}
+		writeJSON(w, http.StatusOK, VolumeData{ID: "v1", Name: r.Form.Get("name")})
+	})
+	volumeOp := NewVolume(fake.authClient(t))
+
+	options := VolumeCreateOptions{BlockSize: 4096, Provision: ProvisionThin, Compress: CompressionLz4, Dedup: true}
+	if _, err := volumeOp.CreateVolume(context.Background(), "sc1", "vol1", 1024, &options); err != nil {
+		t.Fatalf("CreateVolume failed: %v", err)
+	}
+
+	if _, err := volumeOp.CreateVolume(context.Background(), "sc1", "vol2", 1024, nil); err != nil {
+		t.Fatalf("CreateVolume with nil options failed: %v", err)
+	}
+
+	options = VolumeCreateOptions{BlockSize: 3000}
+	if _, err := volumeOp.CreateVolume(context.Background(), "sc1", "vol3", 1024, &options); err == nil {
+		t.Fatalf("CreateVolume with invalid options expected an error")
+	}
+	if n := fake.count(http.MethodPost, "/rest/internal/cloud/containers/sc1/vols"); n != 2 {
+		t.Fatalf("expected 2 create requests, got %d", n)
+	}
+}
+
+func TestResizeVolume(t *testing.T) {
+	fake := newFakeServer(t)
+	vol := VolumeData{ID: "v1", Name: "vol1", SizeMB: 2048}
+	fake.handleJSON(http.MethodGet, "/rest/internal/cloud/containers/sc1/vols/v1", []*VolumeData{&vol})
+	fake.handle(http.MethodPatch, "/rest/internal/cloud/containers/sc1/vols/v1", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		vol.SizeMB, _ = strconv.ParseUint(r.Form.Get("sizeMB"), 10, 64)
+		writeJSON(w, http.StatusOK, EmptyData{})
+	})
+	fake.handleJSON(http.MethodGet, "/rest/internal/cloud/containers/sc1/vols/v2", []VolumeData{})
+	volumeOp := NewVolume(fake.authClient(t))
+	ctx := context.Background()
+	patches := func() int {
+		return fake.count(http.MethodPatch, "/rest/internal/cloud/containers/sc1/vols/v1")
+	}
+
+	if err := volumeOp.ResizeVolume(ctx, "sc1", "v1", 4096); err != nil {
+		t.Fatalf("ResizeVolume failed: %v", err)
+	}
+	if vol.SizeMB != 4096 || patches() != 1 {
+		t.Fatalf("volume was not expanded: %d MB, %d patches", vol.SizeMB, patches())
+	}
+
+	if err := volumeOp.ResizeVolume(ctx, "sc1", "v1", 4096); err != nil {
+		t.Fatalf("ResizeVolume to the same size failed: %v", err)
+	}
+	if patches() != 1 {
+		t.Fatalf("resize to the same size should be a no-op")
+	}
+
+	err := volumeOp.ResizeVolume(ctx, "sc1", "v1", 1024)
+	var shrinkErr *VolumeShrinkError
+	if !errors.As(err, &shrinkErr) {
+		t.Fatalf("expected VolumeShrinkError, got %v", err)
+	}
+	if shrinkErr.CurrentMB != 4096 || shrinkErr.RequestedMB != 1024 || patches() != 1 {
+		t.Fatalf("unexpected shrink result: %+v, %d patches", shrinkErr, patches())
+	}
+
+	if err := volumeOp.ResizeVolumeWithCapacity(ctx, "sc1", "v1", GiB, &VolumeResizeOptions{AllowShrink: true}); err != nil {
+		t.Fatalf("ResizeVolume with AllowShrink failed: %v", err)
+	}
+	if vol.SizeMB != 1024 || patches() != 2 {
+		t.Fatalf("volume was not shrunk: %d MB, %d patches", vol.SizeMB, patches())
+	}
+
+	if err := volumeOp.ResizeVolume(ctx, "sc1", "v2", 1024); err == nil {
+		t.Fatalf("ResizeVolume of a missing volume expected an error")
+	}
+	if err := volumeOp.ResizeVolumeWithOptions(ctx, "sc1", "", 1024, &VolumeResizeOptions{AllowShrink: true}); err == nil {
+		t.Fatalf("ResizeVolume of an empty volume id expected an error")
+	}
+}
+
+func TestExportVolume(t *testing.T) {
+	fake := newFakeServer(t)
+	var access []string
+	share := fake.handleObject("/rest/internal/cloud/containers/sc1/vols/v1/share", func(r *http.Request) (interface{}, error) {
+		r.ParseForm()
+		access = r.Form["access"]
+		export := ExportData{Squash: NfsSquash(r.Form.Get("squash")), ReadOnly: r.Form.Get("access") == "ro"}
+		if clients := r.Form.Get("clients"); clients != "" {
+			export.Clients = strings.Split(clients, ",")
+		}
+		for _, ver := range strings.Split(r.Form.Get("nfsVersions"), ",") {
+			export.Versions = append(export.Versions, NfsVersion(ver))
+		}
+		return export, nil
+	})
+	share.reply = EmptyData{}
+	fake.handleJSON(http.MethodGet, "/rest/internal/cloud/containers/sc1/vols/v1", []VolumeData{{ID: "v1", VMPath: "/share/sc1/v1"}})
+	volumeOp := NewVolume(fake.authClient(t))
+	ctx := context.Background()
+
+	options := ExportOptions{
+		Clients:  []string{"10.0.0.5", "10.0.1.0/24", "*.example.com"},
+		Access:   ExportReadOnly,
+		Squash:   NfsAllSquash,
+		Versions: []NfsVersion{NfsV3, NfsV41},
+	}
+	if err := volumeOp.ExportVolume(ctx, "sc1", "v1", &options); err != nil {
+		t.Fatalf("ExportVolume failed: %v", err)
+	}
+
+	res, err := volumeOp.GetExport(ctx, "sc1", "v1")
+	if err != nil {
+		t.Fatalf("GetExport failed: %v", err)
+	}
+	if !reflect.DeepEqual(res.Clients, options.Clients) || !res.ReadOnly || res.Squash != NfsAllSquash || !reflect.DeepEqual(res.Versions, options.Versions) {
+		t.Fatalf("unexpected export settings: %+v", *res)
+	}
+	if src := res.MountSource("192.168.1.10"); src != "192.168.1.10:/share/sc1/v1" {
+		t.Fatalf("unexpected mount source: %s", src)
+	}
+
+	// only the clients are set, the access keeps the storage default
+	if err := volumeOp.ExportVolume(ctx, "sc1", "v1", &ExportOptions{Clients: []string{"10.0.0.5"}}); err != nil {
+		t.Fatalf("ExportVolume failed: %v", err)
+	}
+	if len(access) != 0 {
+		t.Fatalf("unexpected access %v", access)
+	}
+
+	// an explicit read-write access is sent
+	if err := volumeOp.ExportVolume(ctx, "sc1", "v1", &ExportOptions{Access: ExportReadWrite}); err != nil {
+		t.Fatalf("ExportVolume failed: %v", err)
+	}
+	if !reflect.DeepEqual(access, []string{"rw"}) {
+		t.Fatalf("unexpected access %v", access)
+	}
+
+	invalids := []ExportOptions{
+		{Clients: []string{"10.0.0.0/33"}},
+		{Clients: []string{"bad host"}},
+		{Access: "none"},
+		{Squash: "root"},
+		{Versions: []NfsVersion{"2"}},
+	}
+	expectInvalid(t, invalids, func(i int) error {
+		return volumeOp.ExportVolume(ctx, "sc1", "v1", &invalids[i])
+	})
+	if n := fake.count(http.MethodPost, "/rest/internal/cloud/containers/sc1/vols/v1/share"); n != 3 {
+		t.Fatalf("expected 3 export requests, got %d", n)
+	}
+}
+
+func TestSmbShare(t *testing.T) {
+	fake := newFakeServer(t)
+	fake.handleObject("/rest/internal/cloud/containers/sc1/vols/v1/smb", func(r *http.Request) (interface{}, error) {
+		options := SmbShareOptions{}
+		if err := json.NewDecoder(r.Body).Decode(&options); err != nil {
+			return nil, err
+		}
+		return &SmbShareData{Name: options.Name, ACL: options.ACL, GuestAccess: options.GuestAccess, Protocol: options.Protocol, Path: `\\qsm\` + options.Name}, nil
+	})
+	volumeOp := NewVolume(fake.authClient(t))
+	ctx := context.Background()
+
+	options := SmbShareOptions{
+		Name:     "build",
+		ACL:      []SmbAce{{Type: SmbGroup, Name: "builders", Permission: SmbReadWrite}},
+		Protocol: SmbProtocol3,
+	}
+	res, err := volumeOp.CreateSmbShare(ctx, "sc1", "v1", &options)
+	if err != nil {
+		t.Fatalf("CreateSmbShare failed: %v", err)
+	}
+	if res.Path != `\\qsm\build` || !reflect.DeepEqual(res.ACL, options.ACL) {
+		t.Fatalf("unexpected share: %+v", *res)
+	}
+
+	options.GuestAccess = true
+	options.ACL = append(options.ACL, SmbAce{Type: SmbUser, Name: "ci", Permission: SmbFullControl})
+	if _, err := volumeOp.UpdateSmbShare(ctx, "sc1", "v1", &options); err != nil {
+		t.Fatalf("UpdateSmbShare failed: %v", err)
+	}
+	res, err = volumeOp.GetSmbShare(ctx, "sc1", "v1")
+	if err != nil {
+		t.Fatalf("GetSmbShare failed: %v", err)
+	}
+	if !res.GuestAccess || len(res.ACL) != 2 {
+		t.Fatalf("share was not updated: %+v", *res)
+	}
+
+	if err := volumeOp.DeleteSmbShare(ctx, "sc1", "v1"); err != nil {
+		t.Fatalf("DeleteSmbShare failed: %v", err)
+	}
+	if _, err := volumeOp.GetSmbShare(ctx, "sc1", "v1"); err == nil {
+		t.Fatalf("GetSmbShare of a deleted share expected an error")
+	}
+
+	invalids := []*SmbShareOptions{
+		nil,
+		{},
+		{Name: "a/b"},
+		{Name: strings.Repeat("s", 81)},
+		{Name: "s", ACL: []SmbAce{{Type: "computer", Name: "pc", Permission: SmbReadOnly}}},
+		{Name: "s", ACL: []SmbAce{{Type: SmbUser, Name: "u", Permission: "write"}}},
+		{Name: "s", ACL: []SmbAce{{Type: SmbUser, Permission: SmbReadOnly}}},
+		{Name: "s", Protocol: "SMB1"},
+	}
+	expectInvalid(t, invalids, func(i int) error {
+		_, err := volumeOp.CreateSmbShare(ctx, "sc1", "v1", invalids[i])
+		return err
+	})
+}